@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autoScrollMsg 自动翻页，值为开始自动翻页时的序号
+type autoScrollMsg int
+
+const (
+	minInterval = time.Second
+	maxInterval = time.Minute
+)
+
+// toggleAuto 开始或暂停自动翻页
+func (m *model) toggleAuto() tea.Cmd {
+	m.auto = !m.auto
+	if !m.auto {
+		m.message = "自动翻页已暂停"
+		return nil
+	}
+	m.autoID++
+	return m.autoTick()
+}
+
+// setInterval 调整自动翻页的间隔
+func (m *model) setInterval(interval time.Duration) {
+	m.interval = min(max(interval, minInterval), maxInterval)
+	m.message = fmt.Sprintf("自动翻页间隔 %s", m.interval)
+}
+
+// autoTick 等待下一次自动翻页，间隔在等待结束时才生效，调整后下一页即可体现
+func (m model) autoTick() tea.Cmd {
+	id := m.autoID
+	return tea.Tick(m.interval, func(time.Time) tea.Msg {
+		return autoScrollMsg(id)
+	})
+}
+
+// autoScroll 向下翻一页，到章节末尾时继续阅读下一章
+func (m model) autoScroll(msg autoScrollMsg) (tea.Model, tea.Cmd) {
+	if !m.auto || int(msg) != m.autoID {
+		return m, nil
+	}
+
+	tick := m.autoTick()
+	if reader.GetLoading() || len(m.content) == 0 {
+		return m, tick
+	}
+	if m.cursor < len(m.content)-m.lines {
+		m.cursor = min(m.cursor+m.lines, len(m.content)-m.lines)
+		return m, tick
+	}
+	if reader.HasNext() {
+		m.cursor = 0
+		return m, tea.Batch(func() tea.Msg {
+			return m.fetchNovelContent("down")
+		}, tick)
+	}
+
+	m.auto = false
+	m.message = "已是最新章节，自动翻页已停止"
+	return m, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"novel-reader-go/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAutoScroll(t *testing.T) {
+	reader = parser.NewReaderWithoutUrl()
+	m := model{state: "reading", lines: 3, interval: 5 * time.Second}
+	m.setContent(strings.Repeat("第一段。\n", 10))
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = updated.(model)
+	if !m.auto || cmd == nil {
+		t.Fatal("应开始自动翻页")
+	}
+
+	updated, _ = m.Update(autoScrollMsg(m.autoID))
+	m = updated.(model)
+	if m.cursor != 3 {
+		t.Errorf("应向下翻一页，实际 cursor=%d", m.cursor)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}})
+	m = updated.(model)
+	if !m.auto || m.interval != 6*time.Second {
+		t.Errorf("调整间隔时不应暂停，实际 auto=%v interval=%s", m.auto, m.interval)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	m = updated.(model)
+	if m.auto {
+		t.Error("按其他键应暂停自动翻页")
+	}
+	cursor := m.cursor
+	updated, _ = m.Update(autoScrollMsg(m.autoID))
+	if updated.(model).cursor != cursor {
+		t.Error("暂停后不应继续翻页")
+	}
+
+	m.cursor = len(m.content) - m.lines
+	m.auto = true
+	updated, _ = m.Update(autoScrollMsg(m.autoID))
+	if updated.(model).auto {
+		t.Error("没有下一章时应停止自动翻页")
+	}
+}
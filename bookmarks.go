@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"novel-reader-go/utils"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	highlightStyle = lipgloss.NewStyle().Underline(true)
+	selectStyle    = lipgloss.NewStyle().Reverse(true)
+)
+
+// markItem 书签列表中的一项，书签和高亮共用
+type markItem struct {
+	highlight bool
+	index     int // 在 Bookmarks 或 Highlights 中的下标
+	url       string
+	chapter   string
+	position  utils.Position
+	text      string // 书签名或高亮的文字
+	note      string
+}
+
+// loadHighlights 读取当前书的高亮
+func (m *model) loadHighlights() {
+	if entry, ok := historyManager.Get(m.originUrl); ok {
+		m.highlights = entry.Highlights
+	}
+}
+
+// newInput 创建输入框
+func newInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.Focus()
+	ti.CharLimit = 256
+	ti.Width = 40
+	return ti
+}
+
+// startInput 切换到输入状态，完成后执行 action
+func (m *model) startInput(action string, prompt string, placeholder string) tea.Cmd {
+	m.inputReturn = m.state
+	m.state = "input"
+	m.inputAction = action
+	m.inputPrompt = prompt
+	m.textInput = newInput(placeholder)
+	return textinput.Blink
+}
+
+// selectedRange 返回高亮选择的第一行和最后一行
+func (m model) selectedRange() (int, int) {
+	start := min(m.selStart, m.cursor)
+	end := max(m.selStart, min(m.cursor+m.lines, len(m.content))-1)
+	return start, end
+}
+
+// linesText 拼接若干行的文字，同一段落内的行直接相连
+func (m model) linesText(start int, end int) string {
+	var b strings.Builder
+	for i := start; i <= end && i < len(m.content); i++ {
+		if i > start && m.content[i].Paragraph != m.content[i-1].Paragraph {
+			b.WriteString("\n")
+		}
+		text := m.content[i].Text
+		if i == start || m.content[i].Paragraph != m.content[i-1].Paragraph {
+			text = strings.TrimLeft(text, " 　")
+		}
+		b.WriteString(text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// lineStyles 当前章节中高亮和正在选择的行的样式
+func (m model) lineStyles() map[int]lipgloss.Style {
+	styles := map[int]lipgloss.Style{}
+	paragraphs := utils.SplitParagraphs(m.raw)
+	for _, highlight := range m.highlights {
+		if highlight.URL != reader.GetUrl() {
+			continue
+		}
+		start := utils.Locate(paragraphs, m.content, highlight.Start)
+		end := utils.Locate(paragraphs, m.content, highlight.End)
+		for i := start; i <= end; i++ {
+			styles[i] = highlightStyle
+		}
+	}
+	if m.selecting {
+		start, end := m.selectedRange()
+		for i := start; i <= end; i++ {
+			styles[i] = selectStyle
+		}
+	}
+	return styles
+}
+
+// updateInput 输入书签名、笔记或搜索关键字
+func (m model) updateInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			if m.incrementalSearch() {
+				m.cancelSearch()
+			}
+			m.state = m.inputReturn
+			m.selecting = false
+			return m, nil
+		case "enter":
+			m.state = m.inputReturn
+			return m, m.finishInput(strings.TrimSpace(m.textInput.Value()))
+		}
+	}
+	value := m.textInput.Value()
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.incrementalSearch() && m.textInput.Value() != value {
+		m.previewSearch(strings.TrimSpace(m.textInput.Value()))
+	}
+	return m, cmd
+}
+
+// finishInput 保存书签、高亮或执行搜索
+func (m *model) finishInput(value string) tea.Cmd {
+	paragraphs := utils.SplitParagraphs(m.raw)
+	var err error
+
+	switch m.inputAction {
+	case "bookmark":
+		if value == "" {
+			value = fmt.Sprintf("%s %s", reader.GetTitle(), m.linesText(m.cursor, m.cursor))
+		}
+		err = historyManager.AddBookmark(m.originUrl, utils.Bookmark{
+			Name:         value,
+			URL:          reader.GetUrl(),
+			ChapterTitle: reader.GetTitle(),
+			Position:     utils.PositionAt(paragraphs, m.content, m.cursor),
+		})
+		m.message = "已添加书签"
+	case "highlight":
+		start, end := m.selectedRange()
+		m.selecting = false
+		err = historyManager.AddHighlight(m.originUrl, utils.Highlight{
+			URL:          reader.GetUrl(),
+			ChapterTitle: reader.GetTitle(),
+			Start:        utils.PositionAt(paragraphs, m.content, start),
+			End:          utils.PositionAt(paragraphs, m.content, end),
+			Text:         m.linesText(start, end),
+			Note:         value,
+		})
+		m.message = "已添加高亮"
+		m.loadHighlights()
+	case "search", "searchBackward":
+		// 输入时已经跳到匹配处，从开始输入时的位置重新查找
+		m.cursor = m.searchOrigin
+		if value != "" {
+			m.runSearch(value, m.inputAction == "searchBackward")
+		} else {
+			m.cancelSearch()
+		}
+	case "searchBook":
+		if value != "" {
+			return m.searchBook(value)
+		}
+	case "opdsSearch":
+		if value != "" {
+			return m.searchOPDS(value)
+		}
+	case "sourceSearch":
+		if value != "" {
+			return m.searchSources(value)
+		}
+	}
+
+	if err != nil {
+		m.message = fmt.Sprintf("保存失败: %v", err)
+	}
+	return nil
+}
+
+// openMarks 打开书签列表
+func (m *model) openMarks() {
+	m.marks = nil
+	m.markSelected = 0
+	entry, _ := historyManager.Get(m.originUrl)
+	for i, bookmark := range entry.Bookmarks {
+		m.marks = append(m.marks, markItem{
+			index:    i,
+			url:      bookmark.URL,
+			chapter:  bookmark.ChapterTitle,
+			position: bookmark.Position,
+			text:     bookmark.Name,
+		})
+	}
+	for i, highlight := range entry.Highlights {
+		m.marks = append(m.marks, markItem{
+			highlight: true,
+			index:     i,
+			url:       highlight.URL,
+			chapter:   highlight.ChapterTitle,
+			position:  highlight.Start,
+			text:      highlight.Text,
+			note:      highlight.Note,
+		})
+	}
+	m.state = "bookmarks"
+}
+
+// citation 引用高亮段落时使用的文字
+func citation(item markItem) string {
+	text := fmt.Sprintf("%s\n——《%s》%s", item.text, reader.GetBookTitle(), item.chapter)
+	if item.note != "" {
+		text += "\n笔记：" + item.note
+	}
+	return text
+}
+
+// updateMarks 书签列表的按键处理
+func (m model) updateMarks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	m.message = ""
+	switch {
+	case keyMsg.String() == "ctrl+c":
+		m.done = true
+		m.flushStats()
+		historyManager.Save(m.historyEntry())
+		return m, tea.Quit
+	case keyMsg.String() == "q" || keyMsg.String() == "esc" || key.Matches(keyMsg, keys.Bookmarks):
+		m.state = "reading"
+	case keyMsg.String() == "d":
+		if len(m.marks) == 0 {
+			break
+		}
+		item := m.marks[m.markSelected]
+		var err error
+		if item.highlight {
+			err = historyManager.RemoveHighlight(m.originUrl, item.index)
+		} else {
+			err = historyManager.RemoveBookmark(m.originUrl, item.index)
+		}
+		if err != nil {
+			m.message = fmt.Sprintf("删除失败: %v", err)
+			break
+		}
+		selected := m.markSelected
+		m.openMarks()
+		m.markSelected = min(selected, max(len(m.marks)-1, 0))
+		m.loadHighlights()
+	case keyMsg.String() == "y":
+		if len(m.marks) == 0 {
+			break
+		}
+		if err := clipboard.WriteAll(citation(m.marks[m.markSelected])); err != nil {
+			m.message = fmt.Sprintf("复制失败: %v", err)
+		} else {
+			m.message = "已复制到剪贴板"
+		}
+	case keyMsg.String() == "enter":
+		if len(m.marks) == 0 {
+			break
+		}
+		item := m.marks[m.markSelected]
+		return m.jumpTo(item.url, item.position)
+	case key.Matches(keyMsg, keys.Down):
+		if m.markSelected < len(m.marks)-1 {
+			m.markSelected++
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.markSelected > 0 {
+			m.markSelected--
+		}
+	}
+	return m, nil
+}
+
+// viewMarks 书签列表界面
+func (m model) viewMarks() string {
+	var b strings.Builder
+	b.WriteString("书签\n\n")
+	if len(m.marks) == 0 {
+		b.WriteString("还没有书签，阅读时按 m 添加书签，按 v 选择段落高亮\n")
+	}
+
+	rows := len(m.marks)
+	if m.height > 4 && rows > m.height-4 {
+		rows = m.height - 4
+	}
+	start := 0
+	if m.markSelected >= rows {
+		start = m.markSelected - rows + 1
+	}
+
+	for i := start; i < start+rows && i < len(m.marks); i++ {
+		item := m.marks[i]
+		cursor := "  "
+		if i == m.markSelected {
+			cursor = "> "
+		}
+		kind := "[书签]"
+		if item.highlight {
+			kind = "[高亮]"
+		}
+		text := []rune(strings.ReplaceAll(item.text, "\n", " "))
+		if len(text) > 30 {
+			text = append(text[:30], '…')
+		}
+		line := fmt.Sprintf("%s%s %s\t%s", cursor, kind, string(text), item.chapter)
+		if item.note != "" {
+			line += "\t笔记: " + item.note
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 跳转\ty 复制引用\td 删除\tq 返回"))
+	return b.String()
+}
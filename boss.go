@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bossTickMsg 老板键界面滚动一行，值为进入老板键界面时的序号
+type bossTickMsg int
+
+// fakeOutput 老板键显示的伪装内容，同一行号总是得到相似的内容
+type fakeOutput struct {
+	mode  string
+	lines []string // mode 为 file 时文件的内容
+}
+
+// disguise 当前使用的伪装内容
+var disguise, _ = newFakeOutput(utils.BossConfig{})
+
+var (
+	logMessages = []string{
+		"http: GET /api/v1/orders 200 %dms",
+		"db: query orders by customer_id took %dms",
+		"http: POST /api/v1/events 201 %dms",
+		"cache: refreshed 42 keys in %dms",
+		"worker-3: job processed in %dms",
+		"http: GET /healthz 200 %dms",
+		"scheduler: sync finished in %dms",
+	}
+	testPackages = []string{
+		"github.com/acme/billing/internal/invoice",
+		"github.com/acme/billing/internal/payment",
+		"github.com/acme/billing/internal/report",
+	}
+	testNames = []string{
+		"TestCreate", "TestUpdate", "TestValidate", "TestList",
+		"TestExport", "TestRetry", "TestTimeout", "TestConcurrent",
+	}
+	shellHistory = []string{
+		"$ git status",
+		"On branch main",
+		"Your branch is up to date with 'origin/main'.",
+		"",
+		"nothing to commit, working tree clean",
+		"$ make build",
+		"go build -o bin/server ./cmd/server",
+	}
+)
+
+// newFakeOutput 根据配置创建伪装内容
+func newFakeOutput(config utils.BossConfig) (*fakeOutput, error) {
+	f := &fakeOutput{mode: config.Mode}
+	switch config.Mode {
+	case "", "log":
+		f.mode = "log"
+	case "test", "shell":
+	case "file":
+		data, err := os.ReadFile(config.File)
+		if err != nil {
+			return nil, err
+		}
+		f.lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	default:
+		return nil, fmt.Errorf("未知的伪装内容: %s", config.Mode)
+	}
+	return f, nil
+}
+
+// scrolls 伪装内容是否随时间滚动
+func (f *fakeOutput) scrolls() bool {
+	return f.mode != "shell"
+}
+
+// interval 第 n 行出现前的间隔，看起来不那么规律
+func (f *fakeOutput) interval(n int) time.Duration {
+	return time.Duration(200+n*137%900) * time.Millisecond
+}
+
+// line 第 n 行伪装内容
+func (f *fakeOutput) line(n int) string {
+	switch f.mode {
+	case "test":
+		cycle := len(testNames)*2 + 2
+		i := n % cycle
+		switch {
+		case i < len(testNames)*2 && i%2 == 0:
+			return "=== RUN   " + testNames[i/2]
+		case i < len(testNames)*2:
+			return fmt.Sprintf("--- PASS: %s (%.2fs)", testNames[i/2], float64(n*7%30)/100)
+		case i == len(testNames)*2:
+			return "PASS"
+		}
+		return fmt.Sprintf("ok  \t%s\t%.3fs", testPackages[n/cycle%len(testPackages)], float64(100+n*53%400)/1000)
+	case "shell":
+		return shellHistory[n%len(shellHistory)]
+	case "file":
+		return f.lines[n%len(f.lines)]
+	}
+
+	level := "INFO "
+	switch {
+	case n%13 == 0:
+		level = "WARN "
+	case n%5 == 0:
+		level = "DEBUG"
+	}
+	message := fmt.Sprintf(logMessages[n%len(logMessages)], 3+n*29%180)
+	return fmt.Sprintf("%s %s %s", time.Now().Format("2006-01-02 15:04:05.000"), level, message)
+}
+
+// prompt shell 提示符
+func prompt() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "dev"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s@%s:~/src/server", user, host)
+}
+
+// status 伪装成与内容一致的状态栏
+func (f *fakeOutput) status(progress float64, line int, total int) string {
+	switch f.mode {
+	case "test":
+		return fmt.Sprintf("ok  \t%s\t%.3fs\tcoverage: %.1f%% of statements", testPackages[0], float64(line%1000)/1000, progress)
+	case "shell":
+		return fmt.Sprintf("%s [%d/%d]$ ", prompt(), line, total)
+	}
+	return fmt.Sprintf("%s INFO  sync: %d/%d (%.1f%%)", time.Now().Format("2006-01-02 15:04:05.000"), line, total, progress)
+}
+
+// updateBoss 在任何界面处理老板键，ok 为 false 时交给当前界面处理
+func (m model) updateBoss(msg tea.Msg) (model, tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case bossTickMsg:
+		if m.boss && int(msg) == m.bossID {
+			m.bossCount++
+			return m, m.bossTick(), true
+		}
+		return m, nil, true
+	case tea.KeyMsg:
+		if m.boss {
+			// 老板键界面按任意键恢复，不执行该按键的操作
+			m.boss = false
+			return m, nil, true
+		}
+		if m.state == "input" || m.state == "prompt" || !key.Matches(msg, keys.Boss) {
+			// 输入文字时老板键是普通字符
+			return m, nil, false
+		}
+		m.selecting = false
+		if m.auto {
+			m.auto = false
+			m.message = "自动翻页已暂停"
+		}
+		if m.speaking {
+			m.stopSpeaking()
+			m.message = "朗读已停止"
+		}
+		return m, m.startBoss(), true
+	}
+	return m, nil, false
+}
+
+// startBoss 切换到老板键界面
+func (m *model) startBoss() tea.Cmd {
+	m.boss = true
+	m.bossID++
+	m.bossCount = m.viewHeight()
+	return m.bossTick()
+}
+
+// bossTick 等待滚动下一行
+func (m model) bossTick() tea.Cmd {
+	if !disguise.scrolls() {
+		return nil
+	}
+	id := m.bossID
+	return tea.Tick(disguise.interval(m.bossCount), func(time.Time) tea.Msg {
+		return bossTickMsg(id)
+	})
+}
+
+// viewHeight 阅读界面的行数
+func (m model) viewHeight() int {
+	return m.lines + 1 + config.Padding*2
+}
+
+// viewBoss 老板键界面，与阅读界面的行数相同，避免终端跳动
+func (m model) viewBoss() string {
+	height := m.viewHeight()
+	lines := make([]string, 0, height)
+	if disguise.scrolls() {
+		for n := m.bossCount - height; n < m.bossCount; n++ {
+			lines = append(lines, disguise.line(n))
+		}
+	} else {
+		for n := len(shellHistory) - height + 1; n < len(shellHistory); n++ {
+			if n >= 0 {
+				lines = append(lines, disguise.line(n))
+			} else {
+				lines = append(lines, "")
+			}
+		}
+		lines = append(lines, prompt()+"$ ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBossKey(t *testing.T) {
+	m := model{state: "reading", lines: 3}
+	boss := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}
+
+	updated, cmd := m.Update(boss)
+	m = updated.(model)
+	if !m.boss || cmd == nil {
+		t.Fatal("按老板键应切换到伪装界面并开始滚动")
+	}
+	if lines := strings.Split(m.View(), "\n"); len(lines) != m.lines+1 {
+		t.Errorf("伪装界面应与阅读界面行数相同，实际 %d 行", len(lines))
+	}
+
+	count := m.bossCount
+	updated, _ = m.Update(bossTickMsg(m.bossID - 1))
+	if updated.(model).bossCount != count {
+		t.Error("应忽略上一次遗留的滚动")
+	}
+	updated, _ = m.Update(bossTickMsg(m.bossID))
+	m = updated.(model)
+	if m.bossCount != count+1 {
+		t.Error("伪装内容应滚动一行")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = updated.(model)
+	if m.boss || m.cursor != 0 {
+		t.Error("按任意键应恢复阅读界面，且不执行该按键的操作")
+	}
+}
+
+func TestBossKeyInLists(t *testing.T) {
+	boss := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}
+	m := model{state: "library", lines: 3, library: []utils.HistoryEntry{{OriginURL: "https://example.com/1.html", Title: "某某小说"}}}
+
+	updated, _ := m.Update(boss)
+	m = updated.(model)
+	if !m.boss || strings.Contains(m.View(), "某某小说") {
+		t.Fatal("书架中按老板键应切换到伪装界面")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.boss || m.state != "library" {
+		t.Errorf("按任意键应回到书架，且不打开书，实际 %s", m.state)
+	}
+
+	m.startInput("sourceSearch", "搜索书源：", "")
+	updated, _ = m.Update(boss)
+	m = updated.(model)
+	if m.boss || m.textInput.Value() != "x" {
+		t.Error("输入文字时老板键应作为普通字符")
+	}
+}
+
+func TestFakeOutput(t *testing.T) {
+	if _, err := newFakeOutput(utils.BossConfig{Mode: "vim"}); err == nil {
+		t.Error("未知的伪装内容应返回错误")
+	}
+
+	f, err := newFakeOutput(utils.BossConfig{Mode: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := f.line(0); line != "=== RUN   TestCreate" {
+		t.Errorf("测试输出的第一行有误: %q", line)
+	}
+	if line := f.line(1); !strings.HasPrefix(line, "--- PASS: TestCreate") {
+		t.Errorf("测试输出的第二行有误: %q", line)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// opdsFeedMsg 读取到的 OPDS 目录，more 为 true 时是当前目录的下一页
+type opdsFeedMsg struct {
+	feed *parser.OPDSFeed
+	more bool
+	err  error
+}
+
+// opdsDownloadMsg 下载完成的 EPUB 文件
+type opdsDownloadMsg struct {
+	path string
+	err  error
+}
+
+// catalogFeed 配置了多个书库时，把书库列为一个目录
+func catalogFeed(catalogs []utils.OPDSCatalog) *parser.OPDSFeed {
+	feed := &parser.OPDSFeed{Title: "OPDS 书库"}
+	for _, catalog := range catalogs {
+		name := catalog.Name
+		if name == "" {
+			name = catalog.URL
+		}
+		feed.Entries = append(feed.Entries, parser.OPDSEntry{
+			Title: name,
+			Links: []parser.OPDSLink{{Href: catalog.URL, Type: "application/atom+xml;profile=opds-catalog"}},
+		})
+	}
+	return feed
+}
+
+// openOPDS 打开配置的 OPDS 书库
+func (m *model) openOPDS() tea.Cmd {
+	switch len(config.OPDS) {
+	case 0:
+		m.message = "没有配置 OPDS 书库"
+		return nil
+	case 1:
+		return m.browseOPDS(config.OPDS[0].URL)
+	}
+	m.feeds = []*parser.OPDSFeed{catalogFeed(config.OPDS)}
+	m.feedSelected = 0
+	m.message = ""
+	m.state = "browse"
+	return nil
+}
+
+// browseOPDS 从书库的地址开始浏览
+func (m *model) browseOPDS(url string) tea.Cmd {
+	m.feeds = nil
+	m.state = "browse"
+	return m.loadFeed(url, false)
+}
+
+// loadFeed 在后台读取目录
+func (m *model) loadFeed(url string, more bool) tea.Cmd {
+	m.loading = true
+	m.message = ""
+	return fetchFeed(url, more)
+}
+
+// fetchFeed 读取目录
+func fetchFeed(url string, more bool) tea.Cmd {
+	return func() tea.Msg {
+		feed, err := parser.FetchOPDS(httpClient, url)
+		return opdsFeedMsg{feed: feed, more: more, err: err}
+	}
+}
+
+// searchOPDS 在当前目录所在的书库中搜索
+func (m *model) searchOPDS(query string) tea.Cmd {
+	search := ""
+	for i := len(m.feeds) - 1; i >= 0 && search == ""; i-- {
+		search = m.feeds[i].Search
+	}
+	if search == "" {
+		m.message = "这个书库不支持搜索"
+		return nil
+	}
+	m.loading = true
+	m.message = ""
+	return func() tea.Msg {
+		url, err := parser.OPDSSearchURL(httpClient, search, query)
+		if err != nil {
+			return opdsFeedMsg{err: err}
+		}
+		feed, err := parser.FetchOPDS(httpClient, url)
+		return opdsFeedMsg{feed: feed, err: err}
+	}
+}
+
+// epubPath 下载的 EPUB 保存的位置，文件名带上作者和下载地址的摘要，书名相同的书不会互相覆盖
+func epubPath(entry parser.OPDSEntry) string {
+	name := entry.Title
+	if entry.Author != "" {
+		name += " - " + entry.Author
+	}
+	key := parser.CacheKey(entry.Acquisition(parser.EPUBType))[:8]
+	return filepath.Join(utils.DataDir(), "books", utils.SafeFileName(name, "book")+"_"+key+".epub")
+}
+
+// downloadEPUB 下载 EPUB 到数据目录的 books 下，已下载过时直接使用
+func downloadEPUB(entry parser.OPDSEntry) tea.Cmd {
+	return func() tea.Msg {
+		path := epubPath(entry)
+		if _, err := os.Stat(path); err == nil {
+			return opdsDownloadMsg{path: path}
+		}
+		data, err := parser.Download(httpClient, entry.Acquisition(parser.EPUBType))
+		if err != nil {
+			return opdsDownloadMsg{err: err}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return opdsDownloadMsg{err: err}
+		}
+		// 先写入临时文件，避免中断后留下不完整的 EPUB
+		if err := os.WriteFile(path+".part", data, 0644); err != nil {
+			return opdsDownloadMsg{err: err}
+		}
+		return opdsDownloadMsg{path: path, err: os.Rename(path+".part", path)}
+	}
+}
+
+// feed 当前浏览的目录
+func (m model) feed() *parser.OPDSFeed {
+	if len(m.feeds) == 0 {
+		return nil
+	}
+	return m.feeds[len(m.feeds)-1]
+}
+
+// updateBrowse 浏览 OPDS 书库
+func (m model) updateBrowse(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case opdsFeedMsg:
+		if !m.loading {
+			// 加载时已经返回，忽略
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("读取书库失败: %v", msg.err)
+			return m, nil
+		}
+		if msg.more && m.feed() != nil {
+			// 下一页接在当前目录后面
+			current := *m.feed()
+			current.Entries = append(current.Entries[:len(current.Entries):len(current.Entries)], msg.feed.Entries...)
+			current.Next = msg.feed.Next
+			m.feeds[len(m.feeds)-1] = &current
+			return m, nil
+		}
+		m.feeds = append(m.feeds, msg.feed)
+		m.feedSelected = 0
+		return m, nil
+	case opdsDownloadMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("下载失败: %v", msg.err)
+			return m, nil
+		}
+		entry, ok := historyManager.Get(msg.path)
+		if !ok {
+			entry = utils.HistoryEntry{OriginURL: msg.path, LastURL: msg.path, Convert: config.Convert}
+		}
+		return m, m.openEntry(entry)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	feed := m.feed()
+	var entries []parser.OPDSEntry
+	if feed != nil {
+		entries = feed.Entries
+	}
+
+	switch {
+	case keyMsg.String() == "ctrl+c" || keyMsg.String() == "q":
+		m.done = true
+		return m, tea.Quit
+	case keyMsg.String() == "esc" || keyMsg.String() == "backspace":
+		m.message = ""
+		m.loading = false
+		if len(m.feeds) > 1 {
+			m.feeds = m.feeds[:len(m.feeds)-1]
+			m.feedSelected = 0
+			break
+		}
+		m.feeds = nil
+		m.state = "library"
+	case m.loading:
+		// 加载中不响应其他按键
+	case key.Matches(keyMsg, keys.Search):
+		return m, m.startInput("opdsSearch", "搜索书库:", "书名或作者")
+	case keyMsg.String() == "enter":
+		if len(entries) == 0 {
+			break
+		}
+		entry := entries[m.feedSelected]
+		if url := entry.Navigation(); url != "" {
+			return m, m.loadFeed(url, false)
+		}
+		if entry.Acquisition(parser.EPUBType) == "" {
+			m.message = "这本书没有 EPUB 格式"
+			break
+		}
+		m.loading = true
+		m.message = "正在下载 " + entry.Title
+		return m, downloadEPUB(entry)
+	case key.Matches(keyMsg, keys.Down):
+		if m.feedSelected < len(entries)-1 {
+			m.feedSelected++
+		} else if feed != nil && feed.Next != "" {
+			return m, m.loadFeed(feed.Next, true)
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.feedSelected > 0 {
+			m.feedSelected--
+		}
+	case key.Matches(keyMsg, keys.Top):
+		m.feedSelected = 0
+	case key.Matches(keyMsg, keys.Bottom):
+		m.feedSelected = max(len(entries)-1, 0)
+	}
+	return m, nil
+}
+
+// viewBrowse OPDS 书库界面
+func (m model) viewBrowse() string {
+	var b strings.Builder
+	feed := m.feed()
+	if feed == nil {
+		b.WriteString("OPDS 书库\n\n")
+	} else {
+		b.WriteString(feed.Title + "\n\n")
+		if len(feed.Entries) == 0 {
+			b.WriteString("没有内容\n")
+		}
+
+		rows := len(feed.Entries)
+		if m.height > 4 && rows > m.height-4 {
+			rows = m.height - 4
+		}
+		start := 0
+		if m.feedSelected >= rows {
+			start = m.feedSelected - rows + 1
+		}
+		for i := start; i < start+rows && i < len(feed.Entries); i++ {
+			entry := feed.Entries[i]
+			cursor := "  "
+			if i == m.feedSelected {
+				cursor = "> "
+			}
+			line := cursor + entry.Title
+			if entry.Navigation() != "" {
+				line += "/"
+			} else if entry.Author != "" {
+				line += "\t" + entry.Author
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	switch {
+	case m.loading && m.message == "":
+		b.WriteString("加载中...\n")
+	case m.message != "":
+		b.WriteString(m.message + "\n")
+	}
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 打开\t" + keys.Search.Help().Key + " 搜索\tesc 返回\tq 退出"))
+	return b.String()
+}
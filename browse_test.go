@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBrowseOPDS(t *testing.T) {
+	defer func() { config = utils.DefaultConfig() }()
+	config.OPDS = []utils.OPDSCatalog{{Name: "Calibre", URL: "http://calibre/opds"}, {URL: "http://other/opds"}}
+
+	press := func(m model, key string) (model, tea.Cmd) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		updated, cmd := m.Update(msg)
+		return updated.(model), cmd
+	}
+	receive := func(m model, msg tea.Msg) model {
+		updated, _ := m.Update(msg)
+		return updated.(model)
+	}
+
+	m := model{state: "library"}
+	m, _ = press(m, "o")
+	if m.state != "browse" || len(m.feed().Entries) != 2 || m.feed().Entries[0].Title != "Calibre" {
+		t.Fatalf("配置多个书库时应列出书库，实际 %+v", m.feed())
+	}
+
+	m, cmd := press(m, "enter")
+	if !m.loading || cmd == nil {
+		t.Fatal("选择书库后应开始读取目录")
+	}
+	m = receive(m, opdsFeedMsg{feed: &parser.OPDSFeed{
+		Title:   "全部书籍",
+		Entries: []parser.OPDSEntry{{Title: "第一本"}, {Title: "第二本"}},
+		Next:    "http://calibre/opds/books?offset=2",
+	}})
+	m, _ = press(m, "j")
+	if m.feedSelected != 1 {
+		t.Fatalf("feedSelected = %d, want 1", m.feedSelected)
+	}
+	m, cmd = press(m, "j")
+	if cmd == nil || !m.loading {
+		t.Fatal("到最后一项时应读取下一页")
+	}
+	m = receive(m, opdsFeedMsg{feed: &parser.OPDSFeed{Entries: []parser.OPDSEntry{{Title: "第三本"}}}, more: true})
+	if len(m.feeds) != 2 || len(m.feed().Entries) != 3 || m.feed().Next != "" || m.feedSelected != 1 {
+		t.Fatalf("下一页应接在当前目录后，实际 %d 层 %+v", len(m.feeds), m.feed())
+	}
+
+	m, _ = press(m, "enter")
+	if m.message != "这本书没有 EPUB 格式" {
+		t.Errorf("message = %q", m.message)
+	}
+
+	m, _ = press(m, "/")
+	if m.state != "input" || m.inputAction != "opdsSearch" {
+		t.Fatalf("应开始输入搜索关键字，实际 %s %s", m.state, m.inputAction)
+	}
+	m, _ = press(m, "esc")
+	if m.state != "browse" {
+		t.Fatalf("取消搜索应回到书库，实际 %s", m.state)
+	}
+
+	m, _ = press(m, "esc")
+	if len(m.feeds) != 1 || m.state != "browse" {
+		t.Fatalf("esc 应返回上一层，实际 %d 层", len(m.feeds))
+	}
+	m, _ = press(m, "esc")
+	if m.state != "library" {
+		t.Errorf("在第一层按 esc 应回到书架，实际 %s", m.state)
+	}
+}
+
+func TestEPUBPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	epub := func(title string, author string, href string) parser.OPDSEntry {
+		return parser.OPDSEntry{Title: title, Author: author, Links: []parser.OPDSLink{
+			{Rel: "http://opds-spec.org/acquisition", Type: parser.EPUBType, Href: href},
+		}}
+	}
+
+	a := epubPath(epub("重生", "张三", "http://calibre/get/epub/1"))
+	if a != epubPath(epub("重生", "张三", "http://calibre/get/epub/1")) {
+		t.Error("同一本书应使用同一个文件")
+	}
+	for _, other := range []parser.OPDSEntry{
+		epub("重生", "李四", "http://calibre/get/epub/2"),
+		epub("重生", "张三", "http://calibre/get/epub/3"),
+	} {
+		if path := epubPath(other); path == a {
+			t.Errorf("书名相同的书不应共用文件: %s", path)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// converters 已加载的简繁转换
+var converters = map[string]*utils.Converter{}
+
+// converter 返回简繁转换，name 为空时返回 nil，表示不转换
+func converter(name string) (*utils.Converter, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if c, ok := converters[name]; ok {
+		return c, nil
+	}
+	c, err := utils.NewConverter(name, config.OpenCCDir)
+	if err != nil {
+		return nil, err
+	}
+	converters[name] = c
+	return c, nil
+}
+
+// setConvert 设置当前书的简繁转换
+func (m *model) setConvert(name string) error {
+	c, err := converter(name)
+	if err != nil {
+		return err
+	}
+	m.convert = name
+	reader.SetConvert(c.Convert)
+	return nil
+}
+
+// nextConvert 切换到下一种简繁转换，并按新的转换重新显示本章
+func (m *model) nextConvert() tea.Cmd {
+	names := append([]string{""}, utils.Conversions...)
+	next := ""
+	for i, name := range names {
+		if name == m.convert {
+			next = names[(i+1)%len(names)]
+		}
+	}
+	if err := m.setConvert(next); err != nil {
+		m.message = err.Error()
+		return nil
+	}
+
+	m.message = "简繁转换: 关闭"
+	if next != "" {
+		m.message = "简繁转换: " + next
+	}
+	historyManager.Save(m.historyEntry())
+
+	if m.raw == "" {
+		return nil
+	}
+	// 转换前后段落和字数基本一致，按段落和偏移恢复位置
+	position := m.position()
+	position.Fingerprint = ""
+	m.restore = &position
+	current := reader.Current()
+	return func() tea.Msg {
+		return contentMsg(current)
+	}
+}
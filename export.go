@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+// bookChapter 导出的一章，同一章分成多页时合并为一章
+type bookChapter struct {
+	Index      int // 在目录中的序号，从 1 开始，用于文件名
+	Title      string
+	Paragraphs []string
+}
+
+// bookChapters 按阅读顺序整理章节，应用简繁转换和过滤规则，跳过没有内容的章节。
+// 没有目录或目录中找不到的章节接着上一章编号
+func bookChapters(cached []parser.CachedChapter, catalog []parser.Chapter, convert func(string) string) []bookChapter {
+	var chapters []bookChapter
+	for _, c := range parser.SortChapters(cached, catalog) {
+		title := convert(c.Result.Title)
+		var paragraphs []string
+		for _, p := range utils.SplitParagraphs(rules.Apply(convert(c.Result.Content))) {
+			if strings.TrimSpace(p) != "" {
+				paragraphs = append(paragraphs, p)
+			}
+		}
+		if len(paragraphs) == 0 {
+			continue
+		}
+		if n := len(chapters); n > 0 && title != "" && chapters[n-1].Title == title {
+			chapters[n-1].Paragraphs = append(chapters[n-1].Paragraphs, paragraphs...)
+			continue
+		}
+		index := parser.ChapterIndex(catalog, c.URL) + 1
+		if index == 0 {
+			index = 1
+			if n := len(chapters); n > 0 {
+				index = chapters[n-1].Index + 1
+			}
+		}
+		chapters = append(chapters, bookChapter{Index: index, Title: title, Paragraphs: paragraphs})
+	}
+	return chapters
+}
+
+// bookCatalog 按缓存的章节读取书的目录，读取失败时返回 nil
+func bookCatalog(entry utils.HistoryEntry, cached []parser.CachedChapter) []parser.Chapter {
+	if len(cached) == 0 {
+		return nil
+	}
+	r, err := newReader(entry.OriginURL, entry.LastURL, entry.Source)
+	if err != nil {
+		return nil
+	}
+	catalog, err := r.CatalogOf(cached[len(cached)-1])
+	if err != nil {
+		return nil
+	}
+	return catalog
+}
+
+// cachedBook 一本书已缓存的内容
+type cachedBook struct {
+	Title    string
+	Author   string
+	Chapters []bookChapter
+}
+
+// loadBook 读取书架中一本书已缓存的章节
+func loadBook(entry utils.HistoryEntry) (cachedBook, error) {
+	c, err := converter(entry.Convert)
+	if err != nil {
+		return cachedBook{}, err
+	}
+	cached, err := chapterCache(entry.OriginURL).All()
+	if err != nil {
+		return cachedBook{}, err
+	}
+
+	book := cachedBook{
+		Title:    entry.Title,
+		Author:   c.Convert(cachedAuthor(cached)),
+		Chapters: bookChapters(cached, bookCatalog(entry, cached), c.Convert),
+	}
+	for _, chapter := range cached {
+		if book.Title == "" {
+			book.Title = c.Convert(chapter.Result.BookTitle)
+		}
+	}
+	return book, nil
+}
+
+// cachedAuthor 缓存的章节中第一个不为空的作者
+func cachedAuthor(cached []parser.CachedChapter) string {
+	for _, chapter := range cached {
+		if chapter.Result.Author != "" {
+			return chapter.Result.Author
+		}
+	}
+	return ""
+}
+
+// audioExport 有声书导出
+type audioExport struct {
+	Dir        string
+	Book       string
+	Author     string
+	Codec      string   // wav 或 ogg
+	OggCommand []string // 把 WAV 转换为 OGG 的命令
+	Synthesize func(ctx context.Context, text string) (utils.WAVFormat, []byte, error)
+}
+
+// partial 导出中的临时文件，完成后再改名，中断后重新导出时不会跳过不完整的文件
+func partial(path string, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".part" + ext
+}
+
+// run 把每章合成为一个音频文件并写入播放列表，已导出的章节跳过
+func (e audioExport) run(ctx context.Context, w io.Writer, chapters []bookChapter) error {
+	if err := os.MkdirAll(e.Dir, 0755); err != nil {
+		return err
+	}
+
+	items := make([]utils.PlaylistItem, 0, len(chapters))
+	for i, chapter := range chapters {
+		name := utils.ChapterFileName(chapter.Index, chapter.Title, "."+e.Codec)
+		item := utils.PlaylistItem{File: name, Title: chapter.Title, Seconds: -1}
+		path := filepath.Join(e.Dir, name)
+		if _, err := os.Stat(path); err == nil {
+			fmt.Fprintf(w, "[%d/%d] %s 已存在，跳过\n", i+1, len(chapters), chapter.Title)
+			items = append(items, item)
+			continue
+		}
+
+		fmt.Fprintf(w, "[%d/%d] %s\n", i+1, len(chapters), chapter.Title)
+		seconds, err := e.chapter(ctx, chapter, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", chapter.Title, err)
+		}
+		item.Seconds = seconds
+		items = append(items, item)
+	}
+
+	playlist, err := os.Create(filepath.Join(e.Dir, utils.SafeFileName(e.Book, "playlist")+".m3u"))
+	if err != nil {
+		return err
+	}
+	defer playlist.Close()
+	return utils.WriteM3U(playlist, items)
+}
+
+// chapter 逐段合成一章并写入 path，返回时长（秒）
+func (e audioExport) chapter(ctx context.Context, chapter bookChapter, path string) (int, error) {
+	wav := partial(path, ".wav")
+	defer os.Remove(wav)
+
+	var writer *utils.WAVWriter
+	for _, p := range chapter.Paragraphs {
+		format, data, err := e.Synthesize(ctx, p)
+		if err != nil {
+			if writer != nil {
+				writer.Close()
+			}
+			return 0, err
+		}
+		if writer == nil {
+			if writer, err = utils.NewWAVWriter(wav, format, chapter.Title); err != nil {
+				return 0, err
+			}
+		}
+		if err := writer.Append(format, data); err != nil {
+			writer.Close()
+			return 0, err
+		}
+	}
+	if writer == nil {
+		return 0, errors.New("没有内容")
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	seconds := int(writer.Duration().Seconds())
+
+	if e.Codec == "ogg" {
+		ogg := partial(path, ".ogg")
+		tags := utils.AudioTags{Title: chapter.Title, Artist: e.Author, Album: e.Book}
+		if err := utils.EncodeOgg(ctx, e.OggCommand, wav, ogg, tags); err != nil {
+			os.Remove(ogg)
+			return 0, err
+		}
+		return seconds, os.Rename(ogg, path)
+	}
+	return seconds, os.Rename(wav, path)
+}
+
+// runExport 处理 export 命令，把书架中一本书已缓存的章节导出
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "", "导出格式，目前支持 audio")
+	name := flags.String("book", "", "书名或地址，默认为最近阅读的书")
+	output := flags.String("o", "", "输出目录，默认为当前目录下以书名命名的目录")
+	codec := flags.String("codec", "wav", "音频格式：wav 或 ogg")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "audio" {
+		return fmt.Errorf("不支持的导出格式 %q，请使用 -format audio", *format)
+	}
+	if *codec != "wav" && *codec != "ogg" {
+		return fmt.Errorf("不支持的音频格式 %q，请使用 wav 或 ogg", *codec)
+	}
+
+	library, err := historyManager.Load()
+	if err != nil {
+		return fmt.Errorf("读取历史记录失败: %w", err)
+	}
+	var entry *utils.HistoryEntry
+	for i, b := range library.Books {
+		if *name == "" || b.OriginURL == *name || b.Title == *name {
+			entry = &library.Books[i]
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("书架中没有 %s", *name)
+	}
+
+	if rules, err = utils.LoadRules(config.Rules); err != nil {
+		return fmt.Errorf("读取过滤规则失败: %w", err)
+	}
+	book, err := loadBook(*entry)
+	if err != nil {
+		return err
+	}
+	if len(book.Chapters) == 0 {
+		return errors.New("这本书还没有缓存的章节，请先阅读或预读")
+	}
+	dir := *output
+	if dir == "" {
+		dir = utils.SafeFileName(book.Title, "audiobook")
+	}
+
+	export := audioExport{
+		Dir:        dir,
+		Book:       book.Title,
+		Author:     book.Author,
+		Codec:      *codec,
+		OggCommand: config.TTS.OggCommand,
+		Synthesize: func(ctx context.Context, text string) (utils.WAVFormat, []byte, error) {
+			return utils.SynthesizeWAV(ctx, config.TTS.FileCommand, text)
+		},
+	}
+	// 按 Ctrl+C 时停止合成并删除未完成的文件
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := export.run(ctx, os.Stdout, book.Chapters); err != nil {
+		return err
+	}
+	fmt.Printf("已导出 %d 章到 %s\n", len(book.Chapters), dir)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+func TestBookChapters(t *testing.T) {
+	cached := []parser.CachedChapter{
+		{Result: parser.NovelResult{Title: "第一章", Content: "第一页。"}},
+		{Result: parser.NovelResult{Title: "第一章", Content: "第二页。"}},
+		{Result: parser.NovelResult{Title: "第二章", Content: "\n\n"}},
+		{Result: parser.NovelResult{Title: "第三章", Content: "正文。\n\n下一段。"}},
+	}
+	chapters := bookChapters(cached, nil, func(s string) string { return s })
+	if len(chapters) != 2 {
+		t.Fatalf("应合并分页并跳过空章节，实际 %v", chapters)
+	}
+	if len(chapters[0].Paragraphs) != 2 || len(chapters[1].Paragraphs) != 2 {
+		t.Errorf("段落有误: %v", chapters)
+	}
+}
+
+func TestBookChaptersOrder(t *testing.T) {
+	// 从第三章开始读，之后回到第一章；第三章分为两页
+	cached := []parser.CachedChapter{
+		{URL: "https://example.com/b/3.html", Result: parser.NovelResult{Title: "第三章", Content: "三。", Index: parser.IndexResult{Next: "3_2.html"}}},
+		{URL: "https://example.com/b/3_2.html", Result: parser.NovelResult{Title: "第三章", Content: "三下。", Index: parser.IndexResult{Next: "4.html"}}},
+		{URL: "https://example.com/b/1.html", Result: parser.NovelResult{Title: "第一章", Content: "一。", Index: parser.IndexResult{Next: "2.html"}}},
+	}
+	catalog := []parser.Chapter{
+		{Title: "第一章", URL: "https://example.com/b/1.html"},
+		{Title: "第二章", URL: "https://example.com/b/2.html"},
+		{Title: "第三章", URL: "https://example.com/b/3.html"},
+	}
+
+	chapters := bookChapters(cached, catalog, func(s string) string { return s })
+	if len(chapters) != 2 || chapters[0].Title != "第一章" || chapters[1].Title != "第三章" {
+		t.Fatalf("应按目录顺序排列，实际 %v", chapters)
+	}
+	if chapters[0].Index != 1 || chapters[1].Index != 3 || len(chapters[1].Paragraphs) != 2 {
+		t.Errorf("应按目录中的位置编号并合并分页，实际 %v", chapters)
+	}
+
+	// 没有目录时沿下一页的链接排列
+	cached = append(cached, parser.CachedChapter{URL: "https://example.com/b/2.html", Result: parser.NovelResult{Title: "第二章", Content: "二。", Index: parser.IndexResult{Next: "3.html"}}})
+	chapters = bookChapters(cached, nil, func(s string) string { return s })
+	if len(chapters) != 3 || chapters[0].Title != "第一章" || chapters[1].Title != "第二章" || chapters[2].Index != 3 {
+		t.Errorf("应沿链接排列，实际 %v", chapters)
+	}
+}
+
+func TestExportAudio(t *testing.T) {
+	format := utils.WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 8000, ByteRate: 16000, BlockAlign: 2, BitsPerSample: 16}
+	var texts []string
+	export := audioExport{
+		Dir:   t.TempDir(),
+		Book:  "测试书",
+		Codec: "wav",
+		Synthesize: func(ctx context.Context, text string) (utils.WAVFormat, []byte, error) {
+			texts = append(texts, text)
+			return format, make([]byte, 16000), nil
+		},
+	}
+	chapters := []bookChapter{
+		{Index: 1, Title: "第一章", Paragraphs: []string{"一。", "二。"}},
+		{Index: 2, Title: "第二章", Paragraphs: []string{"三。"}},
+	}
+	if err := export.run(context.Background(), io.Discard, chapters); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 3 {
+		t.Errorf("应逐段合成，实际 %v", texts)
+	}
+
+	f, err := os.Open(filepath.Join(export.Dir, "0001_第一章.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data, err := utils.ReadWAV(f)
+	f.Close()
+	if err != nil || len(data) != 32000 {
+		t.Errorf("第一章应包含两段音频，实际 %d 字节 %v", len(data), err)
+	}
+
+	playlist, err := os.ReadFile(filepath.Join(export.Dir, "测试书.m3u"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXTM3U\n#EXTINF:2,第一章\n0001_第一章.wav\n#EXTINF:1,第二章\n0002_第二章.wav\n"
+	if string(playlist) != want {
+		t.Errorf("播放列表有误:\n%s", playlist)
+	}
+
+	// 再次导出时跳过已有的章节
+	texts = nil
+	if err := export.run(context.Background(), io.Discard, chapters); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 0 {
+		t.Errorf("已导出的章节不应重新合成，实际 %v", texts)
+	}
+	entries, _ := os.ReadDir(export.Dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".part") {
+			t.Errorf("不应留下临时文件 %s", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap 阅读界面的按键绑定，按操作划分
+type keyMap struct {
+	Down           key.Binding
+	Up             key.Binding
+	PageDown       key.Binding
+	PageUp         key.Binding
+	JumpDown       key.Binding
+	JumpUp         key.Binding
+	Top            key.Binding
+	Bottom         key.Binding
+	Bookmark       key.Binding
+	Highlight      key.Binding
+	Bookmarks      key.Binding
+	Search         key.Binding
+	SearchBackward key.Binding
+	SearchBook     key.Binding
+	NextMatch      key.Binding
+	PrevMatch      key.Binding
+	Cancel         key.Binding
+	Help           key.Binding
+	AutoScroll     key.Binding
+	Faster         key.Binding
+	Slower         key.Binding
+	Theme          key.Binding
+	Dim            key.Binding
+	Convert        key.Binding
+	Speak          key.Binding
+	SwitchSource   key.Binding
+	Boss           key.Binding
+	Quit           key.Binding
+}
+
+// keyPresets 内置的按键预设，未列出的操作沿用 default
+var keyPresets = map[string]map[string][]string{
+	"default": {
+		"down":           {"j", "down"},
+		"up":             {"k", "up"},
+		"pageDown":       {"ctrl+f", "pgdown"},
+		"pageUp":         {"ctrl+b", "pgup"},
+		"jumpDown":       {"ctrl+j"},
+		"jumpUp":         {"ctrl+k"},
+		"top":            {"g"},
+		"bottom":         {"G"},
+		"bookmark":       {"m"},
+		"highlight":      {"v"},
+		"bookmarks":      {"B"},
+		"search":         {"/"},
+		"searchBackward": {"?"},
+		"searchBook":     {"S"},
+		"nextMatch":      {"n"},
+		"prevMatch":      {"N"},
+		"cancel":         {"esc"},
+		"help":           {"H", "f1"},
+		"autoScroll":     {"a"},
+		"faster":         {"+", "="},
+		"slower":         {"-"},
+		"theme":          {"t"},
+		"dim":            {"D"},
+		"convert":        {"c"},
+		"speak":          {"r"},
+		"switchSource":   {"s"},
+		"boss":           {"x", "`"},
+		"quit":           {"q", "ctrl+c"},
+	},
+	// less 中 enter、y 也用于滚动，列表界面先处理打开、复制等按键
+	"less": {
+		"down":     {"j", "e", "enter", "down"},
+		"up":       {"k", "y", "up"},
+		"pageDown": {" ", "f", "ctrl+f", "pgdown"},
+		"pageUp":   {"b", "ctrl+b", "pgup"},
+		"jumpDown": {"d", "ctrl+d"},
+		"jumpUp":   {"u", "ctrl+u"},
+		"top":      {"g", "<", "home"},
+		"bottom":   {"G", ">", "end"},
+		"bookmark": {"m"},
+		"help":     {"h", "H"},
+		"quit":     {"q", "Q", "ctrl+c"},
+	},
+	"emacs": {
+		"down":           {"ctrl+n", "down"},
+		"up":             {"ctrl+p", "up"},
+		"pageDown":       {"ctrl+v", "pgdown"},
+		"pageUp":         {"alt+v", "pgup"},
+		"jumpDown":       {"alt+}"},
+		"jumpUp":         {"alt+{"},
+		"top":            {"alt+<", "home"},
+		"bottom":         {"alt+>", "end"},
+		"bookmark":       {"ctrl+@", "ctrl+space"},
+		"highlight":      {"alt+h"},
+		"bookmarks":      {"ctrl+x"},
+		"search":         {"ctrl+s"},
+		"searchBackward": {"ctrl+r"},
+		"searchBook":     {"alt+s"},
+		"nextMatch":      {"alt+n"},
+		"prevMatch":      {"alt+p"},
+		"cancel":         {"ctrl+g", "esc"},
+		"help":           {"f1"},
+		"boss":           {"f12"},
+		"quit":           {"ctrl+c", "ctrl+q"},
+	},
+	// 手柄映射工具通常把摇杆映射为方向键，按钮映射为空格、回车等
+	"gamepad": {
+		"down":         {"down", "s"},
+		"up":           {"up", "w"},
+		"pageDown":     {"right", " ", "d", "pgdown"},
+		"pageUp":       {"left", "a", "pgup"},
+		"jumpDown":     {"e"},
+		"jumpUp":       {"q"},
+		"top":          {"home"},
+		"bottom":       {"end"},
+		"bookmark":     {"b"},
+		"bookmarks":    {"tab"},
+		"autoScroll":   {"enter"},
+		"switchSource": {},
+		"help":         {"f1", "h"},
+		"quit":         {"ctrl+c", "backspace"},
+	},
+}
+
+// actions 操作名与说明，顺序即帮助中的顺序
+var actions = []struct {
+	name string
+	desc string
+}{
+	{"down", "向下滚动"},
+	{"up", "向上滚动"},
+	{"pageDown", "向下翻页"},
+	{"pageUp", "向上翻页"},
+	{"jumpDown", "向下翻十页"},
+	{"jumpUp", "向上翻十页"},
+	{"top", "跳转到开头"},
+	{"bottom", "跳转到末尾"},
+	{"bookmark", "添加书签"},
+	{"highlight", "选择段落高亮"},
+	{"bookmarks", "书签列表"},
+	{"search", "向下搜索"},
+	{"searchBackward", "向上搜索"},
+	{"searchBook", "搜索全书"},
+	{"nextMatch", "下一个结果"},
+	{"prevMatch", "上一个结果"},
+	{"cancel", "取消"},
+	{"help", "帮助"},
+	{"autoScroll", "自动翻页"},
+	{"faster", "加快自动翻页"},
+	{"slower", "减慢自动翻页"},
+	{"theme", "切换主题"},
+	{"dim", "暗淡模式"},
+	{"convert", "简繁转换"},
+	{"speak", "朗读"},
+	{"switchSource", "换源"},
+	{"boss", "老板键"},
+	{"quit", "退出"},
+}
+
+// binding 返回操作名对应的绑定
+func (k *keyMap) binding(name string) *key.Binding {
+	switch name {
+	case "down":
+		return &k.Down
+	case "up":
+		return &k.Up
+	case "pageDown":
+		return &k.PageDown
+	case "pageUp":
+		return &k.PageUp
+	case "jumpDown":
+		return &k.JumpDown
+	case "jumpUp":
+		return &k.JumpUp
+	case "top":
+		return &k.Top
+	case "bottom":
+		return &k.Bottom
+	case "bookmark":
+		return &k.Bookmark
+	case "highlight":
+		return &k.Highlight
+	case "bookmarks":
+		return &k.Bookmarks
+	case "search":
+		return &k.Search
+	case "searchBackward":
+		return &k.SearchBackward
+	case "searchBook":
+		return &k.SearchBook
+	case "nextMatch":
+		return &k.NextMatch
+	case "prevMatch":
+		return &k.PrevMatch
+	case "cancel":
+		return &k.Cancel
+	case "help":
+		return &k.Help
+	case "autoScroll":
+		return &k.AutoScroll
+	case "faster":
+		return &k.Faster
+	case "slower":
+		return &k.Slower
+	case "theme":
+		return &k.Theme
+	case "dim":
+		return &k.Dim
+	case "convert":
+		return &k.Convert
+	case "speak":
+		return &k.Speak
+	case "switchSource":
+		return &k.SwitchSource
+	case "boss":
+		return &k.Boss
+	case "quit":
+		return &k.Quit
+	}
+	return nil
+}
+
+// newKeyMap 根据配置生成按键绑定
+func newKeyMap(config utils.KeyMapConfig) (keyMap, error) {
+	preset := config.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	presetKeys, ok := keyPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("未知的按键预设: %s", preset)
+	}
+
+	var k keyMap
+	for _, action := range actions {
+		keys, ok := config.Bindings[action.name]
+		if !ok {
+			keys, ok = presetKeys[action.name]
+		}
+		if !ok {
+			keys = keyPresets["default"][action.name]
+		}
+		b := k.binding(action.name)
+		*b = key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKeys(keys), action.desc))
+		if len(keys) == 0 {
+			b.SetEnabled(false)
+		}
+	}
+
+	for name := range config.Bindings {
+		if k.binding(name) == nil {
+			return keyMap{}, fmt.Errorf("未知的操作: %s", name)
+		}
+	}
+	return k, nil
+}
+
+// helpKeys 帮助中显示的按键
+func helpKeys(keys []string) string {
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k == " " {
+			k = "space"
+		}
+		names = append(names, k)
+	}
+	return strings.Join(names, "/")
+}
+
+// listHelp 列表界面中选择操作的提示
+func listHelp() string {
+	return keys.Down.Help().Key + "，" + keys.Up.Help().Key + " 选择"
+}
+
+// ShortHelp 实现 help.KeyMap
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Down, k.Up, k.Help, k.Quit}
+}
+
+// FullHelp 实现 help.KeyMap
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Down, k.Up, k.PageDown, k.PageUp, k.JumpDown, k.JumpUp, k.Top, k.Bottom},
+		{k.Bookmark, k.Highlight, k.Bookmarks, k.Cancel},
+		{k.Search, k.SearchBackward, k.SearchBook, k.SwitchSource, k.NextMatch, k.PrevMatch},
+		{k.AutoScroll, k.Faster, k.Slower, k.Theme, k.Dim, k.Convert, k.Speak},
+		{k.Help, k.Boss, k.Quit},
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	k, err := newKeyMap(utils.KeyMapConfig{
+		Preset:   "less",
+		Bindings: map[string][]string{"bookmark": {"M"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	if !key.Matches(space, k.PageDown) {
+		t.Error("less 预设中空格应向下翻页")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'M'}}, k.Bookmark) {
+		t.Error("配置应覆盖预设中的按键")
+	}
+	if key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}}, k.Bookmark) {
+		t.Error("被覆盖的按键不应再生效")
+	}
+	// less 预设未设置的操作沿用默认按键
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}}, k.Search) {
+		t.Error("未设置的操作应使用默认按键")
+	}
+
+	if _, err := newKeyMap(utils.KeyMapConfig{Preset: "vi"}); err == nil {
+		t.Error("未知的预设应返回错误")
+	}
+	if _, err := newKeyMap(utils.KeyMapConfig{Bindings: map[string][]string{"jump": {"x"}}}); err == nil {
+		t.Error("未知的操作应返回错误")
+	}
+}
+
+func TestLessPresetLists(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	reader = parser.NewReaderWithoutUrl()
+	less, err := newKeyMap(utils.KeyMapConfig{Preset: "less"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = less
+	defer func() {
+		keys, _ = newKeyMap(utils.KeyMapConfig{})
+		historyManager = utils.NewHistoryManager()
+	}()
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m := model{state: "library", library: []utils.HistoryEntry{{OriginURL: "https://example.com/1.html", LastURL: "https://example.com/1.html"}}}
+	updated, _ := m.Update(enter)
+	if state := updated.(model).state; state != "reading" {
+		t.Errorf("书架中 enter 应打开书，实际 %s", state)
+	}
+
+	m = model{state: "bookmarks", marks: []markItem{{text: "书签"}}}
+	updated, _ = m.Update(enter)
+	if state := updated.(model).state; state != "reading" {
+		t.Errorf("书签列表中 enter 应跳到书签，实际 %s", state)
+	}
+
+	m = model{state: "searchResults", results: []searchHit{{chapter: "第一章"}}}
+	updated, _ = m.Update(enter)
+	if state := updated.(model).state; state != "reading" {
+		t.Errorf("搜索结果中 enter 应跳到结果，实际 %s", state)
+	}
+
+	m = model{state: "toc", toc: []parser.Chapter{{Title: "第一章", URL: "https://example.com/1.html"}}}
+	updated, _ = m.Update(enter)
+	if state := updated.(model).state; state != "reading" {
+		t.Errorf("目录中 enter 应打开章节，实际 %s", state)
+	}
+}
+
+func TestFullHelp(t *testing.T) {
+	k, err := newKeyMap(utils.KeyMapConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shown := map[string]bool{}
+	for _, group := range k.FullHelp() {
+		for _, b := range group {
+			shown[b.Help().Desc] = true
+		}
+	}
+	v := reflect.ValueOf(k)
+	for i := 0; i < v.NumField(); i++ {
+		b := v.Field(i).Interface().(key.Binding)
+		if !shown[b.Help().Desc] {
+			t.Errorf("帮助中没有 %s（%s）", v.Type().Field(i).Name, strings.Join(b.Keys(), "/"))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateLibrary 书架界面的按键处理
+func (m model) updateLibrary(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, keys.Quit):
+		m.done = true
+		return m, tea.Quit
+	case keyMsg.String() == "d":
+		if len(m.library) == 0 {
+			break
+		}
+		if err := historyManager.Remove(m.library[m.selected].OriginURL); err != nil {
+			m.message = fmt.Sprintf("删除失败: %v", err)
+			break
+		}
+		m.library = append(m.library[:m.selected], m.library[m.selected+1:]...)
+		if m.selected >= len(m.library) {
+			m.selected = max(len(m.library)-1, 0)
+		}
+	case keyMsg.String() == "enter":
+		if len(m.library) == 0 {
+			break
+		}
+		cmd := m.openEntry(m.library[m.selected])
+		return m, cmd
+	case keyMsg.String() == "o":
+		return m, m.openOPDS()
+	case keyMsg.String() == "s":
+		if len(config.Sources) == 0 {
+			m.message = "没有配置书源"
+			break
+		}
+		return m, m.startInput("sourceSearch", "搜索书源：", "书名或作者")
+	case key.Matches(keyMsg, keys.Down):
+		if m.selected < len(m.library)-1 {
+			m.selected++
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.selected > 0 {
+			m.selected--
+		}
+	case key.Matches(keyMsg, keys.Top):
+		m.selected = 0
+	case key.Matches(keyMsg, keys.Bottom):
+		m.selected = max(len(m.library)-1, 0)
+	}
+	return m, nil
+}
+
+// openEntry 按阅读记录打开一本书，从上次的位置继续
+func (m *model) openEntry(entry utils.HistoryEntry) tea.Cmd {
+	r, err := newReader(entry.OriginURL, entry.LastURL, entry.Source)
+	if err != nil {
+		m.message = fmt.Sprintf("打开失败: %v", err)
+		return nil
+	}
+	reader = r
+	m.originUrl = entry.OriginURL
+	m.catalog, m.catalogLoaded = nil, false
+	m.chapterIndex, m.chapterTotal = entry.ChapterIndex, entry.ChapterTotal
+	if err := m.setConvert(entry.Convert); err != nil {
+		m.message = err.Error()
+	}
+	m.source = entry.Source
+	m.history = entry
+	if entry.Position.Fingerprint != "" {
+		m.restore = &entry.Position
+	} else {
+		m.cursor = entry.Cursor
+	}
+	m.state = "reading"
+	return m.fetch("")
+}
+
+// viewLibrary 书架界面
+func (m model) viewLibrary() string {
+	var b strings.Builder
+	b.WriteString("书架\n\n")
+	if len(m.library) == 0 {
+		b.WriteString("书架是空的，按 s 搜索书源或按 o 打开 OPDS 书库\n")
+	}
+
+	// 终端高度不够时只显示选中项附近的书
+	rows := len(m.library)
+	if m.height > 4 && rows > m.height-4 {
+		rows = m.height - 4
+	}
+	start := 0
+	if m.selected >= rows {
+		start = m.selected - rows + 1
+	}
+
+	for i := start; i < start+rows && i < len(m.library); i++ {
+		book := m.library[i]
+		title := book.Title
+		if title == "" {
+			title = book.OriginURL
+		}
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		b.WriteString(fmt.Sprintf("%s%s\t%s\t%.2f%%\t%s\n",
+			cursor, title, book.ChapterTitle, book.Progress, book.LastRead.Format("2006-01-02 15:04")))
+	}
+
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	help := listHelp() + "\tenter 打开\td 删除\t"
+	if len(config.Sources) > 0 {
+		help += "s 搜索书源\t"
+	}
+	if len(config.OPDS) > 0 {
+		help += "o OPDS 书库\t"
+	}
+	b.WriteString(helpStyle.Render(help + keys.Quit.Help().Key + " 退出"))
+	return b.String()
+}
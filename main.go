@@ -1,53 +1,32 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"novel-reader-go/parser"
 	"novel-reader-go/utils"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// wordWrap 将文本按指定宽度分割成数组
-func wordWrap(str string, maxWidth int) []string {
-	// 统一替换所有换行符为\n
-	str = strings.ReplaceAll(str, "\r\n", "\n")
-	str = strings.ReplaceAll(str, "\r", "\n")
-
-	lines := strings.Split(str, "\n")
-	var newLines []string
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		runes := []rune(line)
-		for len(runes) > 0 {
-			newLines = append(newLines, string(runes[:min(maxWidth, len(runes))]))
-			runes = runes[min(maxWidth, len(runes)):]
-		}
-	}
-	return newLines
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 var (
 	reader         *parser.Reader
 	helpStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	historyManager = utils.NewHistoryManager()
+	keys, _        = newKeyMap(utils.KeyMapConfig{})
+	config         = utils.DefaultConfig()
+	rules          *utils.Rules
+	httpClient     parser.HttpClient = parser.NewDefaultHttpClient()
 )
 
 // 定义模型
@@ -55,12 +34,135 @@ type model struct {
 	state     string
 	textInput textinput.Model
 	originUrl string
+	source    string // JSON 接口配置文件
+	convert   string // 简繁转换
 	lines     int
-	content   []string
+	raw       string       // 当前章节的原始内容
+	content   []utils.Line // 折行后的内容
 	cursor    int
 	done      bool
+	width     int // 终端宽度
+	height    int // 终端高度
+	maxWidth  int // 最大折行宽度，0 表示跟随终端宽度
+
+	// restore 等待内容加载后恢复的阅读位置
+	restore *utils.Position
 
 	history utils.HistoryEntry
+
+	// 书架
+	library  []utils.HistoryEntry
+	selected int
+	message  string
+
+	// 书签和高亮
+	inputAction  string // 输入完成后的操作：bookmark 或 highlight
+	inputPrompt  string
+	inputReturn  string // 输入结束后返回的界面
+	selecting    bool   // 正在选择要高亮的段落
+	selStart     int
+	highlights   []utils.Highlight
+	marks        []markItem
+	markSelected int
+
+	// 搜索
+	search         string
+	searchBackward bool
+	searchOrigin   int    // 开始输入关键字时的行，边输入边搜索时从这里查找
+	searchPrev     string // 开始输入前的关键字，取消时恢复
+	matches        []utils.Match
+	results        []searchHit
+	resultSelected int
+
+	// OPDS 书库
+	feeds        []*parser.OPDSFeed // 进入过的目录，最后一个为当前目录
+	feedSelected int
+	loading      bool
+
+	// 书源搜索和目录
+	bookQuery    string
+	books        []parser.BookResult
+	bookSelected int
+	bookSource   int // 选中的书使用的书源
+	searchErrors []error
+	toc          []parser.Chapter
+	tocBook      parser.SearchResult
+	tocSelected  int
+	tocReturn    string // 在目录中按 esc 返回的界面
+
+	// 换源
+	switchEntry      utils.HistoryEntry // 换源前的阅读记录
+	switchTitle      string             // 当前章节未经转换的标题
+	switchCandidates []parser.SearchResult
+	switchSelected   int
+
+	showHelp bool
+
+	// 老板键
+	boss      bool
+	bossID    int // 每次进入老板键界面时加一，用来忽略上一次遗留的滚动
+	bossCount int // 已显示的伪装内容行数
+
+	// 自动翻页
+	auto     bool
+	autoID   int // 每次开始自动翻页时加一，用来忽略暂停前遗留的翻页
+	interval time.Duration
+
+	// 朗读
+	speaking    bool
+	speakID     int // 每次开始朗读时加一，用来忽略停止前遗留的朗读结果
+	sentences   []utils.Sentence
+	sentence    int // 正在朗读的句子
+	cancelSpeak context.CancelFunc
+
+	// 全书进度和阅读统计
+	catalog        []parser.Chapter
+	catalogLoaded  bool
+	chapterIndex   int
+	chapterTotal   int
+	readTo         int // 本章已统计字数的行
+	lastActive     time.Time
+	pendingTime    time.Duration // 还未写入历史记录的阅读时长
+	pendingChars   int
+	sessionStarted bool
+}
+
+// newReader 根据地址和 JSON 接口配置创建 reader，originURL 用于区分每本书的章节缓存
+func newReader(originURL string, url string, source string) (*parser.Reader, error) {
+	var r *parser.Reader
+	if source == "" {
+		r = parser.NewReaderUrlWithClient(url, httpClient)
+	} else {
+		jsonSource, err := parser.LoadJSONSource(source)
+		if err != nil {
+			return nil, err
+		}
+		r = parser.NewReaderWithParser(parser.NewJSONParser(httpClient, jsonSource))
+		r.SetUrl(url)
+	}
+	r.SetCache(chapterCache(originURL))
+	r.SetPrefetch(config.Prefetch)
+	return r, nil
+}
+
+// setupHttpClient 按网络设置创建 httpClient
+func setupHttpClient() error {
+	client, err := parser.NewHttpClient(parser.HttpOptions{
+		Timeout:   time.Duration(config.Network.Timeout) * time.Second,
+		Retries:   config.Network.Retries,
+		Proxy:     config.Network.Proxy,
+		UserAgent: config.Network.UserAgent,
+	})
+	if err != nil {
+		return fmt.Errorf("网络设置有误: %w", err)
+	}
+	httpClient = client
+	return nil
+}
+
+// chapterCache 返回一本书的章节缓存
+func chapterCache(originURL string) *parser.ChapterCache {
+	return parser.NewChapterCache(filepath.Join(utils.CacheDir(), "chapters", parser.CacheKey(originURL)))
 }
 
 // 初始命令
@@ -75,15 +177,20 @@ func (m model) fetchNovelContent(direction string) tea.Msg {
 		novelContent, err = reader.ReadPrev()
 	case "down":
 		novelContent, err = reader.ReadNext()
+	case "nextChapter":
+		novelContent, err = reader.ReadNextChapter()
+	case "prevChapter":
+		novelContent, err = reader.ReadPrevChapter()
 	default:
 		novelContent, err = reader.Read()
 	}
 
-	historyManager.Save(utils.HistoryEntry{
-		OriginURL: m.originUrl,
-		LastURL:   reader.GetUrl(),
-		Cursor:    m.cursor,
-	})
+	entry := m.historyEntry()
+	if direction != "" && err == nil && !novelContent.EndOfBook {
+		// 翻到新的章节，从头开始
+		entry.Cursor, entry.Position, entry.Progress = 0, utils.Position{}, 0
+	}
+	historyManager.Save(entry)
 
 	if err != nil {
 		return errMsg(err)
@@ -92,8 +199,15 @@ func (m model) fetchNovelContent(direction string) tea.Msg {
 }
 
 func (m model) Init() tea.Cmd {
-	if m.state == "prompt" {
+	switch m.state {
+	case "prompt":
 		return textinput.Blink
+	case "library":
+		return nil
+	case "browse":
+		return fetchFeed(config.OPDS[0].URL, false)
+	case "books":
+		return fetchBooks(m.bookQuery)
 	}
 
 	return func() tea.Msg {
@@ -101,6 +215,104 @@ func (m model) Init() tea.Cmd {
 	}
 }
 
+// wrapWidth 折行宽度：终端宽度减去左右空白，不超过 maxWidth
+func (m model) wrapWidth() int {
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	width = max(width-config.Margin*2, 10)
+	if m.maxWidth > 0 && m.maxWidth < width {
+		width = m.maxWidth
+	}
+	return width
+}
+
+// setContent 设置章节内容并按当前宽度折行
+func (m *model) setContent(content string) {
+	m.raw = rules.Apply(content)
+	m.content = utils.WordWrap(m.raw, m.wrapWidth())
+}
+
+// rewrap 重新折行，阅读位置保持在同一段落
+func (m *model) rewrap() {
+	paragraph, offset := 0, 0
+	if m.cursor >= 0 && m.cursor < len(m.content) {
+		paragraph, offset = m.content[m.cursor].Paragraph, m.content[m.cursor].Offset
+	}
+	m.content = utils.WordWrap(m.raw, m.wrapWidth())
+	m.cursor = utils.FindLine(m.content, paragraph, offset)
+}
+
+// position 当前阅读位置
+func (m model) position() utils.Position {
+	if m.restore != nil {
+		return *m.restore
+	}
+	return utils.PositionAt(utils.SplitParagraphs(m.raw), m.content, m.cursor)
+}
+
+// progress 当前章节的阅读进度
+func (m model) progress() float64 {
+	if len(m.content) == 0 {
+		return 0
+	}
+	return float64(m.cursor+1) / float64(len(m.content)) * 100
+}
+
+// historyEntry 当前阅读位置对应的历史记录
+func (m model) historyEntry() utils.HistoryEntry {
+	return utils.HistoryEntry{
+		OriginURL:    m.originUrl,
+		LastURL:      reader.GetUrl(),
+		Cursor:       m.cursor,
+		Position:     m.position(),
+		Source:       m.source,
+		Convert:      m.convert,
+		Title:        reader.GetBookTitle(),
+		ChapterTitle: reader.GetTitle(),
+		Progress:     m.progress(),
+		ChapterIndex: m.chapterIndex,
+		ChapterTotal: m.chapterTotal,
+		ChapterChars: countChars(m.raw),
+	}
+}
+
+// lineDown 向下滚动，到本章末尾时读取下一页或下一章
+func (m *model) lineDown() tea.Cmd {
+	if m.cursor < len(m.content)-m.lines {
+		m.cursor += m.lines
+		if m.cursor > len(m.content)-m.lines {
+			m.cursor = len(m.content) - m.lines
+		}
+	} else if reader.HasNext() {
+		m.cursor = 0
+		return m.fetch("down")
+	}
+	return nil
+}
+
+// lineUp 向上滚动，到本章开头时读取上一页或上一章
+func (m *model) lineUp() tea.Cmd {
+	if m.cursor > 0 {
+		m.cursor -= m.lines
+		if m.cursor < 0 {
+			m.cursor = 0
+		}
+	} else if reader.HasPrev() {
+		m.cursor = 0
+		return m.fetch("up")
+	}
+	return nil
+}
+
+// fetch 在后台读取章节
+func (m model) fetch(direction string) tea.Cmd {
+	return func() tea.Msg {
+		return m.fetchNovelContent(direction)
+	}
+}
+
 // 自定义消息类型
 type contentMsg *parser.NovelResult
 type errMsg error
@@ -109,7 +321,45 @@ type errMsg error
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = msg.Width
+		m.height = msg.Height
+		if m.raw != "" {
+			m.rewrap()
+		}
+	}
+
+	if msg, ok := msg.(catalogMsg); ok {
+		// 换源或换书后，原来的 reader 解析出的目录已经没用
+		if msg.reader == reader {
+			m.setCatalog(msg.chapters)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(remoteMsg); ok {
+		return m.remote(msg)
+	}
+	if updated, cmd, ok := m.updateBoss(msg); ok {
+		return updated, cmd
+	}
+
 	switch m.state {
+	case "library":
+		return m.updateLibrary(msg)
+	case "input":
+		return m.updateInput(msg)
+	case "bookmarks":
+		return m.updateMarks(msg)
+	case "searchResults":
+		return m.updateResults(msg)
+	case "browse":
+		return m.updateBrowse(msg)
+	case "books":
+		return m.updateBooks(msg)
+	case "toc":
+		return m.updateTOC(msg)
+	case "switch":
+		return m.updateSwitch(msg)
 	case "prompt":
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
@@ -122,7 +372,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.textInput.Value() == "Y" || m.textInput.Value() == "y" || m.textInput.Value() == "" {
 					entry := m.history
 					m.originUrl = entry.OriginURL
-					m.cursor = entry.Cursor
+					m.chapterIndex, m.chapterTotal = entry.ChapterIndex, entry.ChapterTotal
+					if err := m.setConvert(entry.Convert); err != nil {
+						m.message = err.Error()
+					}
+					if entry.Position.Fingerprint != "" {
+						m.restore = &entry.Position
+					} else {
+						m.cursor = entry.Cursor
+					}
 					reader.SetUrl(entry.LastURL)
 					return m, func() tea.Msg {
 						return m.fetchNovelContent("")
@@ -139,46 +397,127 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case "reading":
 		switch msg := msg.(type) {
 		case contentMsg:
-			m.content = wordWrap(msg.Content, 40)
-			return m, nil
+			if msg.EndOfBook {
+				// 已是最新章节，保留当前内容并停在末尾
+				if len(m.content) == 0 {
+					m.setContent(msg.Content)
+				}
+				m.cursor = max(len(m.content)-m.lines, 0)
+				if m.speaking {
+					m.stopSpeaking()
+					m.message = "已是最新章节，朗读已停止"
+				}
+				return m, nil
+			}
+			m.flushStats()
+			m.setContent(msg.Content)
+			m.selecting = false
+			m.loadHighlights()
+			m.findMatches()
+			if m.restore != nil {
+				m.cursor = utils.Locate(utils.SplitParagraphs(m.raw), m.content, *m.restore)
+				m.restore = nil
+			}
+			m.readTo = m.cursor
+			m.locateChapter()
+			if m.speaking {
+				// 朗读到下一章时从头继续
+				m.loadSentences()
+				return m, tea.Batch(m.speakCurrent(), m.loadCatalog())
+			}
+			return m, m.loadCatalog()
 		case errMsg:
-			m.content = []string{fmt.Sprintf("错误: %v", msg)}
+			m.stopSpeaking()
+			m.setContent(fmt.Sprintf("错误: %v", msg))
 			return m, nil
+		case autoScrollMsg:
+			m.track()
+			return m.autoScroll(msg)
+		case spokenMsg:
+			m.track()
+			return m.spoken(msg)
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "q", "ctrl+c":
+			m.message = ""
+			m.track()
+			if m.auto && !key.Matches(msg, keys.AutoScroll, keys.Faster, keys.Slower) {
+				// 按其他键时暂停自动翻页
+				m.auto = false
+				m.message = "自动翻页已暂停"
+			}
+			if m.speaking {
+				// 按任意键停止朗读
+				m.stopSpeaking()
+				m.message = "朗读已停止"
+				if key.Matches(msg, keys.Speak) {
+					return m, nil
+				}
+			}
+			if m.showHelp {
+				// 帮助界面按任意键关闭
+				m.showHelp = false
+				return m, nil
+			}
+			if m.selecting && msg.String() == "enter" {
+				return m, m.startInput("highlight", "笔记（可留空）：", "")
+			}
+
+			switch {
+			case key.Matches(msg, keys.Quit):
 				m.done = true
-				historyManager.Save(utils.HistoryEntry{
-					OriginURL: m.originUrl,
-					LastURL:   reader.GetUrl(),
-					Cursor:    m.cursor,
-				})
+				m.stopSpeaking()
+				m.flushStats()
+				historyManager.Save(m.historyEntry())
 				return m, tea.Quit
-			case "j", "down":
-				if m.cursor < len(m.content)-m.lines {
-					m.cursor += m.lines
-					if m.cursor > len(m.content)-m.lines {
-						m.cursor = len(m.content) - m.lines
-					}
-				} else if reader.HasNext() {
-					m.cursor = 0
-					return m, func() tea.Msg {
-						return m.fetchNovelContent("down")
-					}
+			case key.Matches(msg, keys.Help):
+				m.showHelp = true
+			case key.Matches(msg, keys.AutoScroll):
+				return m, m.toggleAuto()
+			case key.Matches(msg, keys.Speak):
+				return m, m.startSpeaking()
+			case key.Matches(msg, keys.SwitchSource):
+				return m, m.startSwitch()
+			case key.Matches(msg, keys.Faster):
+				m.setInterval(m.interval - time.Second)
+			case key.Matches(msg, keys.Slower):
+				m.setInterval(m.interval + time.Second)
+			case key.Matches(msg, keys.Theme):
+				m.nextTheme()
+			case key.Matches(msg, keys.Dim):
+				m.toggleDim()
+			case key.Matches(msg, keys.Convert):
+				return m, m.nextConvert()
+			case key.Matches(msg, keys.Bookmark):
+				if len(m.content) > 0 {
+					return m, m.startInput("bookmark", "书签名称：", reader.GetTitle())
 				}
-			case "k", "up":
-				if m.cursor > 0 {
-					m.cursor -= m.lines
-					if m.cursor < 0 {
-						m.cursor = 0
-					}
-				} else if reader.HasPrev() {
-					m.cursor = 0
-					return m, func() tea.Msg {
-						return m.fetchNovelContent("down")
-					}
+			case key.Matches(msg, keys.Highlight):
+				if m.selecting {
+					return m, m.startInput("highlight", "笔记（可留空）：", "")
+				}
+				if len(m.content) > 0 {
+					m.selecting = true
+					m.selStart = m.cursor
+				}
+			case key.Matches(msg, keys.Cancel):
+				m.selecting = false
+				m.search, m.matches = "", nil
+			case key.Matches(msg, keys.Bookmarks):
+				m.openMarks()
+			case key.Matches(msg, keys.Search):
+				return m, m.startSearch(false)
+			case key.Matches(msg, keys.SearchBackward):
+				return m, m.startSearch(true)
+			case key.Matches(msg, keys.SearchBook):
+				return m, m.startInput("searchBook", "搜索全书：", "在已缓存的章节中搜索")
+			case key.Matches(msg, keys.NextMatch, keys.PrevMatch):
+				if m.search != "" {
+					m.jumpMatch(m.searchBackward != key.Matches(msg, keys.PrevMatch))
 				}
-			case "ctrl+f", "pagedown":
+			case key.Matches(msg, keys.Down):
+				return m, m.lineDown()
+			case key.Matches(msg, keys.Up):
+				return m, m.lineUp()
+			case key.Matches(msg, keys.PageDown):
 				m.cursor += m.lines
 				if m.cursor > len(m.content)-m.lines {
 					m.cursor = len(m.content) - m.lines
@@ -186,12 +525,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < 0 {
 					m.cursor = 0
 				}
-			case "ctrl+b", "pageup":
+			case key.Matches(msg, keys.PageUp):
 				m.cursor -= m.lines
 				if m.cursor < 0 {
 					m.cursor = 0
 				}
-			case "ctrl+j":
+			case key.Matches(msg, keys.JumpDown):
 				m.cursor += m.lines * 10
 				if m.cursor > len(m.content)-m.lines {
 					m.cursor = len(m.content) - m.lines
@@ -199,14 +538,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.cursor < 0 {
 					m.cursor = 0
 				}
-			case "ctrl+k":
+			case key.Matches(msg, keys.JumpUp):
 				m.cursor -= m.lines * 10
 				if m.cursor < 0 {
 					m.cursor = 0
 				}
-			case "g":
+			case key.Matches(msg, keys.Top):
 				m.cursor = 0
-			case "G":
+			case key.Matches(msg, keys.Bottom):
 				m.cursor = len(m.content) - m.lines
 				if m.cursor < 0 {
 					m.cursor = 0
@@ -223,7 +562,29 @@ func (m model) View() string {
 		return "再见！\n"
 	}
 
+	if m.boss {
+		return m.viewBoss()
+	}
+
 	switch m.state {
+	case "library":
+		return m.viewLibrary()
+	case "bookmarks":
+		return m.viewMarks()
+	case "searchResults":
+		return m.viewResults()
+	case "browse":
+		return m.viewBrowse()
+	case "books":
+		return m.viewBooks()
+	case "toc":
+		return m.viewTOC()
+	case "switch":
+		return m.viewSwitch()
+	case "input":
+		if !m.incrementalSearch() {
+			return fmt.Sprintf("%s\n%s\n", m.inputPrompt, m.textInput.View()) + "\n"
+		}
 	case "prompt":
 		return fmt.Sprintf(
 			"是否继续上次的阅读？\n%s\n",
@@ -231,79 +592,246 @@ func (m model) View() string {
 		) + "\n"
 	}
 
-	output := ""
+	if m.showHelp {
+		h := help.New()
+		h.ShowAll = true
+		h.Width = m.width
+		return h.View(keys) + "\n" + helpStyle.Render("按任意键返回")
+	}
+
+	output := strings.Repeat("\n", config.Padding)
+	margin := strings.Repeat(" ", m.leftMargin())
 
 	if reader.GetLoading() || len(m.content) == 0 {
 		for i := 0; i < m.lines; i++ {
 			output += "\n"
 		}
-		output += helpStyle.Render(fmt.Sprintf("%.2f%%\t%d/%d\t%s", 100.0, 0, 0, "内容加载中..."))
+		status := fmt.Sprintf("%.2f%%\t%d/%d\t%s", 100.0, 0, 0, "内容加载中...")
+		if config.Boss.Status {
+			status = disguise.status(0, 0, 0)
+		}
+		output += margin + helpStyle.Render(status)
 	} else {
 		end := m.cursor + m.lines
 		if end > len(m.content) {
 			end = len(m.content)
 		}
+		styles := m.lineStyles()
+		paragraphs := utils.SplitParagraphs(m.raw)
 		for i := m.cursor; i < end; i++ {
-			output += fmt.Sprintf("%s\n", m.content[i])
+			text := m.content[i].Text
+			if style, ok := styles[i]; ok {
+				text = style.Render(text)
+			} else if len(m.matches) > 0 {
+				text = m.renderMatches(i, paragraphs)
+			} else {
+				text = m.renderText(i, paragraphs)
+			}
+			output += fmt.Sprintf("%s%s\n", margin, text)
 		}
-		progress := float64(m.cursor+1) / float64(len(m.content)) * 100
+		progress := m.progress()
 		title := reader.GetTitle()
-		output += helpStyle.Render(fmt.Sprintf("%.2f%%\t%d/%d\t%s", progress, m.cursor+1, len(m.content), title))
+		if reader.IsEnd() && m.cursor+m.lines >= len(m.content) {
+			title += " [已是最新章节]"
+		}
+		if m.auto {
+			title += fmt.Sprintf(" [自动翻页 %s]", m.interval)
+		}
+		if m.speaking {
+			title += " [朗读中]"
+		}
+		if m.selecting {
+			title += " [选择高亮：v/enter 完成，esc 取消]"
+		} else if m.message != "" {
+			title += " " + m.message
+		}
+		status := fmt.Sprintf("%s%.2f%%\t%d/%d\t%s", m.bookProgress(), progress, m.cursor+1, len(m.content), title)
+		if config.Boss.Status {
+			// 状态栏不显示书名和章节名
+			status = disguise.status(progress, m.cursor+1, len(m.content))
+		}
+		if m.incrementalSearch() {
+			// 边输入边搜索时在状态栏输入关键字
+			output += margin + m.inputPrompt + m.textInput.View() + " " + helpStyle.Render(m.message)
+		} else {
+			output += margin + helpStyle.Render(status)
+		}
 	}
 
-	return output
+	return output + strings.Repeat("\n", config.Padding)
 }
 
 func main() {
 	// 解析命令行参数
 	var (
-		url   string
-		lines int
+		url        string
+		lines      int
+		width      int
+		jsonSource string
+		remote     string
+		opds       string
 	)
-	flag.StringVar(&url, "read", "", "章节地址")
-	flag.IntVar(&lines, "n", 1, "显示的行数")
-	flag.Parse()
+	if err := utils.MigrateLegacyDir(); err != nil {
+		fmt.Printf("迁移 ~/.nvrd 失败: %v\n", err)
+	}
 
-	if url == "" {
-		fmt.Println("使用方法: novel-reader-go -read <章节地址> [-n 行数]")
+	var err error
+	config, err = utils.LoadConfig()
+	if err != nil {
+		fmt.Printf("读取配置失败: %v\n", err)
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		library, err := historyManager.Load()
+		if err != nil {
+			fmt.Printf("读取历史记录失败: %v\n", err)
+			return
+		}
+		printStats(os.Stdout, library, time.Now())
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			fmt.Printf("网页阅读出错: %v\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			fmt.Printf("导出失败: %v\n", err)
+		}
 		return
 	}
 
-	// 初始化reader
-	reader = parser.NewReaderUrl(url)
+	flag.StringVar(&url, "read", "", "章节地址")
+	flag.IntVar(&lines, "n", config.Lines, "显示的行数")
+	flag.IntVar(&width, "w", config.Width, "每行最大显示宽度，0 表示跟随终端宽度")
+	flag.StringVar(&jsonSource, "json", "", "JSON 接口配置文件")
+	flag.StringVar(&remote, "remote", config.Remote.Listen, "远程控制接口的监听地址，例如 127.0.0.1:7878")
+	flag.StringVar(&opds, "opds", "", "浏览 OPDS 书库，例如 http://localhost:8080/opds")
+	args := os.Args[1:]
+	search := len(args) > 0 && args[0] == "search"
+	if search {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
+	if url != "" && !strings.HasPrefix(url, "http") {
+		// 本地文件用绝对路径记录，换个目录也能从书架打开
+		if abs, err := filepath.Abs(url); err == nil {
+			url = abs
+		}
+	}
+
+	keys, err = newKeyMap(config.Keymap)
+	if err != nil {
+		fmt.Printf("读取按键配置失败: %v\n", err)
+		return
+	}
+	if err := applyTheme(config.Theme, config.Dim); err != nil {
+		fmt.Printf("读取主题失败: %v\n", err)
+		return
+	}
+	disguise, err = newFakeOutput(config.Boss)
+	if err != nil {
+		fmt.Printf("读取老板键设置失败: %v\n", err)
+		return
+	}
+	speaker = newSpeaker(config.TTS)
+	defer closeSpeaker()
+	rules, err = utils.LoadRules(config.Rules)
+	if err != nil {
+		fmt.Printf("读取过滤规则失败: %v\n", err)
+		return
+	}
+	if err := setupHttpClient(); err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	// 创建初始模型
 	initialModel := model{
 		originUrl: url,
 		lines:     lines,
+		maxWidth:  width,
 		state:     "reading",
+		interval:  min(max(time.Duration(config.Interval)*time.Second, minInterval), maxInterval),
 	}
 
-	reader.SetUrl(url)
-
-	// 检查历史记录
-	historyDir := path.Join(os.Getenv("HOME"), ".nvrd")
-	historyFile := path.Join(historyDir, "history.json")
-
-	if _, err := os.Stat(historyFile); err == nil {
-		data, err := os.ReadFile(historyFile)
-		if err == nil {
-			var entry utils.HistoryEntry
-			if json.Unmarshal(data, &entry) == nil {
-				// 创建textinput模型
-				ti := textinput.New()
-				ti.Placeholder = "Y/n"
-				ti.Focus()
-				ti.CharLimit = 156
-				ti.Width = 20
-				initialModel.textInput = ti
-				initialModel.state = "prompt"
-				initialModel.history = entry
+	if opds != "" {
+		config.OPDS = []utils.OPDSCatalog{{URL: opds}}
+	}
+	query := strings.Join(flag.Args(), " ")
+	if search && (query == "" || len(config.Sources) == 0) {
+		fmt.Println("使用方法: novel-reader-go search <关键字>，需要先在配置文件中添加书源")
+		return
+	}
+	if url == "" {
+		// 没有指定地址时打开书架
+		library, err := historyManager.Load()
+		if err != nil || len(library.Books) == 0 && len(config.OPDS) == 0 && !search {
+			fmt.Println("使用方法: novel-reader-go -read <章节地址或 EPUB 文件> [-n 行数] [-w 宽度] [-json 接口配置] [-remote 地址]")
+			fmt.Println("          novel-reader-go search <关键字>  在书源中搜索书名或作者")
+			fmt.Println("          novel-reader-go -opds <书库地址>  浏览 OPDS 书库")
+			fmt.Println("          novel-reader-go stats  查看阅读统计")
+			fmt.Println("          novel-reader-go serve [-listen 地址]  在局域网中网页阅读")
+			fmt.Println("          novel-reader-go export -format audio [-book 书名] [-codec wav|ogg] [-o 目录]  导出有声书")
+			return
+		}
+		initialModel.state = "library"
+		initialModel.library = library.Books
+		switch {
+		case search:
+			initialModel.state = "books"
+			initialModel.bookQuery = query
+			initialModel.loading = true
+		case opds != "":
+			initialModel.state = "browse"
+			initialModel.loading = true
+		}
+	} else {
+		if jsonSource != "" {
+			if abs, err := filepath.Abs(jsonSource); err == nil {
+				jsonSource = abs
 			}
 		}
+
+		// 初始化reader
+		reader, err = newReader(url, url, jsonSource)
+		if err != nil {
+			fmt.Printf("读取接口配置失败: %v\n", err)
+			return
+		}
+		reader.SetUrl(url)
+		initialModel.source = jsonSource
+		if err := initialModel.setConvert(config.Convert); err != nil {
+			fmt.Printf("读取简繁转换失败: %v\n", err)
+			return
+		}
+
+		// 检查这本书的历史记录
+		if entry, ok := historyManager.Get(url); ok {
+			// 创建textinput模型
+			ti := textinput.New()
+			ti.Placeholder = "Y/n"
+			ti.Focus()
+			ti.CharLimit = 156
+			ti.Width = 20
+			initialModel.textInput = ti
+			initialModel.state = "prompt"
+			initialModel.history = entry
+		}
 	}
 
 	p := tea.NewProgram(initialModel)
+	if remote != "" {
+		server, err := startRemote(remote, config.Remote.Token, p.Send)
+		if err != nil {
+			fmt.Printf("启动远程控制失败: %v\n", err)
+			return
+		}
+		defer server.Close()
+	}
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("出错了: %v", err)
 	}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+const (
+	opdsNavigation  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsAcquisition = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	openSearchType  = "application/opensearchdescription+xml"
+)
+
+// opdsLink Atom 链接
+type opdsLink struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+// opdsContent 条目的说明
+type opdsContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// opdsAuthor Atom 作者
+type opdsAuthor struct {
+	Name string `xml:"name"`
+}
+
+// opdsEntry 目录中的一项，没有作者时使用目录的作者
+type opdsEntry struct {
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Author  *opdsAuthor  `xml:"author,omitempty"`
+	Content *opdsContent `xml:"content,omitempty"`
+	Links   []opdsLink   `xml:"link"`
+}
+
+// opdsFeed OPDS 1.2 目录
+type opdsFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  opdsAuthor  `xml:"author"`
+	Links   []opdsLink  `xml:"link"`
+	Entries []opdsEntry `xml:"entry"`
+}
+
+// atomTime Atom 使用的时间格式
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// bookQuery 链接中标识一本书的参数
+func bookQuery(originURL string) string {
+	return "book=" + url.QueryEscape(originURL)
+}
+
+// writeFeed 输出 OPDS 目录，Atom 要求目录或每一项都有作者
+func writeFeed(w http.ResponseWriter, kind string, feed opdsFeed) {
+	feed.Author = opdsAuthor{Name: "novel-reader"}
+	feed.Links = append([]opdsLink{
+		{Rel: "self", Href: feed.ID, Type: kind},
+		{Rel: "start", Href: "/opds", Type: opdsNavigation},
+		{Rel: "search", Href: "/opds/search.xml", Type: openSearchType},
+	}, feed.Links...)
+	w.Header().Set("Content-Type", kind+";charset=utf-8")
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(feed)
+}
+
+// opdsRoot 导航目录
+func (s *webServer) opdsRoot(w http.ResponseWriter, r *http.Request) {
+	now := atomTime(time.Now())
+	writeFeed(w, opdsNavigation, opdsFeed{
+		ID:      "/opds",
+		Title:   "novel-reader",
+		Updated: now,
+		Entries: []opdsEntry{
+			{
+				Title:   "全部书籍",
+				ID:      "/opds/books",
+				Updated: now,
+				Content: &opdsContent{Type: "text", Text: "已缓存章节的书，按最近阅读排序"},
+				Links:   []opdsLink{{Rel: "subsection", Href: "/opds/books", Type: opdsAcquisition}},
+			},
+		},
+	})
+}
+
+// opdsBooks 书籍目录，q 不为空时按书名和地址搜索
+func (s *webServer) opdsBooks(w http.ResponseWriter, r *http.Request) {
+	library, err := historyManager.Load()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query := strings.TrimSpace(r.FormValue("q"))
+	feed := opdsFeed{ID: "/opds/books", Title: "全部书籍", Updated: atomTime(time.Now())}
+	if query != "" {
+		feed.ID = "/opds/books?q=" + url.QueryEscape(query)
+		feed.Title = "搜索: " + query
+	}
+	for _, b := range library.Books {
+		name := bookName(b)
+		if query != "" && !containsFold(name, query) && !containsFold(b.OriginURL, query) {
+			continue
+		}
+		cached, _ := chapterCache(b.OriginURL).All()
+		if len(cached) == 0 {
+			continue
+		}
+		var author *opdsAuthor
+		if a := cachedAuthor(cached); a != "" {
+			c, _ := converter(b.Convert)
+			author = &opdsAuthor{Name: c.Convert(a)}
+		}
+		q := bookQuery(b.OriginURL)
+		feed.Entries = append(feed.Entries, opdsEntry{
+			Title:   name,
+			ID:      "urn:novel-reader:" + parser.CacheKey(b.OriginURL),
+			Updated: atomTime(b.LastRead),
+			Author:  author,
+			Content: &opdsContent{Type: "text", Text: fmt.Sprintf("已缓存 %d 页，读到 %s", len(cached), b.ChapterTitle)},
+			Links: []opdsLink{
+				{Rel: "http://opds-spec.org/acquisition", Href: "/opds/download/epub?" + q, Type: "application/epub+zip"},
+				{Rel: "http://opds-spec.org/acquisition", Href: "/opds/download/txt?" + q, Type: "text/plain"},
+				{Rel: "alternate", Href: "/read?" + q, Type: "text/html", Title: "网页阅读"},
+			},
+		})
+	}
+	writeFeed(w, opdsAcquisition, feed)
+}
+
+// containsFold 不区分大小写判断 s 是否包含 substr
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// openSearch OpenSearch 描述，阅读器据此拼出搜索地址
+func (s *webServer) openSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", openSearchType+";charset=utf-8")
+	fmt.Fprintf(w, `%s<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <ShortName>novel-reader</ShortName>
+  <Description>搜索书架中的书</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <OutputEncoding>UTF-8</OutputEncoding>
+  <Url type="%s" template="/opds/books?q={searchTerms}"/>
+</OpenSearchDescription>
+`, xml.Header, opdsAcquisition)
+}
+
+// download 按缓存的章节生成 EPUB 或 TXT
+func (s *webServer) download(w http.ResponseWriter, r *http.Request) {
+	format := r.PathValue("format")
+	if format != "epub" && format != "txt" {
+		http.NotFound(w, r)
+		return
+	}
+	entry, ok := book(w, r)
+	if !ok {
+		return
+	}
+	b, err := loadBook(entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(b.Chapters) == 0 {
+		http.Error(w, "这本书还没有缓存的章节", http.StatusNotFound)
+		return
+	}
+
+	name := utils.SafeFileName(b.Title, "book")
+	if format == "txt" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(name+".txt"))
+		writeTXT(w, b)
+		return
+	}
+
+	chapters := make([]utils.EPUBChapter, len(b.Chapters))
+	for i, c := range b.Chapters {
+		chapters[i] = utils.EPUBChapter{Title: c.Title, Paragraphs: c.Paragraphs}
+	}
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(name+".epub"))
+	utils.WriteEPUB(w, utils.EPUBBook{
+		ID:       "urn:novel-reader:" + parser.CacheKey(entry.OriginURL),
+		Title:    b.Title,
+		Author:   b.Author,
+		Modified: entry.LastRead,
+		Chapters: chapters,
+	})
+}
+
+// writeTXT 把书写成纯文本，章节之间空一行
+func writeTXT(w io.Writer, b cachedBook) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, b.Title)
+	if b.Author != "" {
+		fmt.Fprintln(out, "作者: "+b.Author)
+	}
+	for _, chapter := range b.Chapters {
+		fmt.Fprintf(out, "\n%s\n\n", chapter.Title)
+		for _, p := range chapter.Paragraphs {
+			fmt.Fprintln(out, strings.TrimSpace(p))
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+func TestOPDS(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	defer func() { historyManager = utils.NewHistoryManager() }()
+
+	book := "https://example.com/book/1.html"
+	historyManager.Save(utils.HistoryEntry{OriginURL: book, LastURL: book, Title: "测试书"})
+	historyManager.Save(utils.HistoryEntry{OriginURL: "https://example.com/other/1.html", Title: "没有缓存"})
+	chapterCache(book).Put(book, parser.NovelResult{Title: "第一章", Author: "作者", Content: "第一段\n第二段"})
+
+	handler := newWebServer().handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s 返回 %d: %s", path, rec.Code, rec.Body)
+		}
+		return rec
+	}
+
+	var feed opdsFeed
+	if err := xml.Unmarshal(get("/opds").Body.Bytes(), &feed); err != nil || len(feed.Entries) != 1 {
+		t.Fatalf("导航目录有误: %v %+v", err, feed)
+	}
+
+	rec := get("/opds/books")
+	if !strings.Contains(rec.Header().Get("Content-Type"), "kind=acquisition") {
+		t.Errorf("类型有误: %s", rec.Header().Get("Content-Type"))
+	}
+	feed = opdsFeed{}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil || len(feed.Entries) != 1 || feed.Entries[0].Title != "测试书" {
+		t.Fatalf("应只列出有缓存的书: %v %+v", err, feed)
+	}
+	if feed.Author.Name != "novel-reader" || feed.Entries[0].Author == nil || feed.Entries[0].Author.Name != "作者" {
+		t.Errorf("目录和书都应有作者: %+v %+v", feed.Author, feed.Entries[0].Author)
+	}
+	var epub string
+	for _, link := range feed.Entries[0].Links {
+		if link.Type == "application/epub+zip" {
+			epub = link.Href
+		}
+	}
+
+	feed = opdsFeed{}
+	xml.Unmarshal(get("/opds/books?q="+url.QueryEscape("没有")).Body.Bytes(), &feed)
+	if len(feed.Entries) != 0 {
+		t.Errorf("搜索结果有误: %+v", feed.Entries)
+	}
+	if body := get("/opds/search.xml").Body.String(); !strings.Contains(body, "{searchTerms}") {
+		t.Errorf("OpenSearch 描述有误:\n%s", body)
+	}
+
+	if body := get("/opds/download/txt?" + bookQuery(book)).Body.String(); body != "测试书\n作者: 作者\n\n第一章\n\n第一段\n第二段\n" {
+		t.Errorf("TXT 内容有误:\n%s", body)
+	}
+
+	data := get(epub).Body.Bytes()
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.File[0].Name != "mimetype" || z.File[0].Method != zip.Store {
+		t.Error("mimetype 应为第一个不压缩的文件")
+	}
+	for _, f := range z.File {
+		if f.Name == "OEBPS/chapter0001.xhtml" {
+			rc, _ := f.Open()
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			if !strings.Contains(string(content), "<p>第二段</p>") {
+				t.Errorf("章节内容有误:\n%s", content)
+			}
+			return
+		}
+	}
+	t.Error("EPUB 中没有章节文件")
+}
+
+func TestOPDSDownloadOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	defer func() { historyManager = utils.NewHistoryManager() }()
+
+	// 先读了第二章，再回到第一章
+	book := "https://example.com/book/2.html"
+	historyManager.Save(utils.HistoryEntry{OriginURL: book, LastURL: "https://example.com/book/1.html", Title: "测试书"})
+	cache := chapterCache(book)
+	cache.Put(book, parser.NovelResult{Title: "第二章", Content: "二"})
+	cache.Put("https://example.com/book/1.html", parser.NovelResult{Title: "第一章", Content: "一", Index: parser.IndexResult{Next: "2.html"}})
+
+	rec := httptest.NewRecorder()
+	newWebServer().handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/opds/download/txt?"+bookQuery(book), nil))
+	if body := rec.Body.String(); body != "测试书\n\n第一章\n\n一\n\n第二章\n\n二\n" {
+		t.Errorf("章节应按阅读顺序排列:\n%s", body)
+	}
+}
@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachedChapter 缓存的章节
+type CachedChapter struct {
+	URL    string      `json:"url"`
+	Result NovelResult `json:"result"`
+	Saved  time.Time   `json:"saved"` // 第一次缓存的时间
+}
+
+// ChapterCache 按书保存读过的章节，用于全书搜索
+type ChapterCache struct {
+	dir string
+}
+
+// NewChapterCache 创建章节缓存，dir 为这本书的缓存目录
+func NewChapterCache(dir string) *ChapterCache {
+	return &ChapterCache{dir: dir}
+}
+
+// CacheKey 将地址转换为缓存使用的文件名
+func CacheKey(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *ChapterCache) file(url string) string {
+	return filepath.Join(c.dir, CacheKey(url)+".json")
+}
+
+// Get 读取缓存的章节
+func (c *ChapterCache) Get(url string) (CachedChapter, bool) {
+	var chapter CachedChapter
+	data, err := os.ReadFile(c.file(url))
+	if err != nil {
+		return chapter, false
+	}
+	if json.Unmarshal(data, &chapter) != nil {
+		return chapter, false
+	}
+	return chapter, true
+}
+
+// Put 缓存章节，已缓存过的章节保留第一次缓存的时间
+func (c *ChapterCache) Put(url string, result NovelResult) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	chapter := CachedChapter{URL: url, Result: result, Saved: time.Now()}
+	if cached, ok := c.Get(url); ok {
+		chapter.Saved = cached.Saved
+	}
+	data, err := json.Marshal(chapter)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.file(url), data, 0644)
+}
+
+// Count 返回缓存的页数，不读取内容
+func (c *ChapterCache) Count() int {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			n++
+		}
+	}
+	return n
+}
+
+// All 返回所有缓存的章节，按第一次缓存的时间排序
+func (c *ChapterCache) All() ([]CachedChapter, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var chapters []CachedChapter
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var chapter CachedChapter
+		if json.Unmarshal(data, &chapter) == nil {
+			chapters = append(chapters, chapter)
+		}
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Saved.Before(chapters[j].Saved)
+	})
+	return chapters, nil
+}
+
+// SortChapters 按阅读顺序排列缓存的章节。沿下一页的链接把相连的页面排在一起，
+// 各段按在目录中的位置排序，目录中找不到的按缓存时间排在后面
+func SortChapters(cached []CachedChapter, catalog []Chapter) []CachedChapter {
+	byURL := map[string]int{}
+	for i, chapter := range cached {
+		byURL[strings.TrimSuffix(chapter.URL, "/")] = i
+	}
+	next := make([]int, len(cached))
+	linked := make([]bool, len(cached)) // 有其他页面链接到这一页
+	for i, chapter := range cached {
+		next[i] = -1
+		if chapter.Result.Index.Next == "" {
+			continue
+		}
+		nextURL := strings.TrimSuffix(resolveURL(chapter.URL, chapter.Result.Index.Next), "/")
+		if j, ok := byURL[nextURL]; ok && j != i && !linked[j] {
+			next[i], linked[j] = j, true
+		}
+	}
+
+	// run 连续的一段页面，index 为其中第一个在目录中的章节的位置
+	type run struct {
+		pages []int
+		index int
+	}
+	var runs []run
+	visited := make([]bool, len(cached))
+	walk := func(start int) {
+		r := run{index: -1}
+		for i := start; i >= 0 && !visited[i]; i = next[i] {
+			visited[i] = true
+			r.pages = append(r.pages, i)
+			if r.index < 0 {
+				r.index = ChapterIndex(catalog, cached[i].URL)
+			}
+		}
+		runs = append(runs, r)
+	}
+	for i := range cached {
+		if !linked[i] {
+			walk(i)
+		}
+	}
+	for i := range cached {
+		// 首尾相连的链接
+		if !visited[i] {
+			walk(i)
+		}
+	}
+
+	sort.SliceStable(runs, func(a, b int) bool {
+		if (runs[a].index >= 0) != (runs[b].index >= 0) {
+			return runs[a].index >= 0
+		}
+		return runs[a].index < runs[b].index
+	})
+	sorted := make([]CachedChapter, 0, len(cached))
+	for _, r := range runs {
+		for _, i := range r.pages {
+			sorted = append(sorted, cached[i])
+		}
+	}
+	return sorted
+}
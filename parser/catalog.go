@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrNoCatalog 当前章节没有目录页
+var ErrNoCatalog = errors.New("没有目录页")
+
+// Chapter 目录中的一章
+type Chapter struct {
+	Title string
+	URL   string
+}
+
+// CatalogParser 可以解析目录的解析器
+type CatalogParser interface {
+	ParseCatalog(url string) ([]Chapter, error)
+}
+
+// ParseCatalog 解析目录页中的章节链接。
+// 很多站点会在完整目录前列出“最新章节”，重复的链接以最后一次出现的位置为准
+func (p *GeneralParser) ParseCatalog(url string) ([]Chapter, error) {
+	if url == "" {
+		return nil, ErrNoCatalog
+	}
+	body, err := p.fetchUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []Chapter
+	document.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		title := normalizeSpace(s.Text())
+		href, _ := s.Attr("href")
+		if !chapterTitlePattern.MatchString(title) || strings.HasPrefix(href, "javascript:") {
+			return
+		}
+		chapters = append(chapters, Chapter{Title: title, URL: resolveURL(url, href)})
+	})
+
+	seen := map[string]bool{}
+	unique := make([]Chapter, 0, len(chapters))
+	for i := len(chapters) - 1; i >= 0; i-- {
+		key := strings.TrimSuffix(chapters[i].URL, "/")
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, chapters[i])
+		}
+	}
+	for i, j := 0, len(unique)-1; i < j; i, j = i+1, j-1 {
+		unique[i], unique[j] = unique[j], unique[i]
+	}
+	return unique, nil
+}
+
+// ParseCatalog 纯文本文件只有一章
+func (p *PlainTextParser) ParseCatalog(url string) ([]Chapter, error) {
+	if url == "" {
+		url = p.url
+	}
+	return []Chapter{{Title: path.Base(url), URL: url}}, nil
+}
+
+// ChapterIndex 返回地址在目录中的位置，找不到时返回 -1
+func ChapterIndex(chapters []Chapter, url string) int {
+	url = strings.TrimSuffix(url, "/")
+	for i, chapter := range chapters {
+		if strings.TrimSuffix(chapter.URL, "/") == url {
+			return i
+		}
+	}
+	return -1
+}
+
+// Catalog 解析当前章节所在书的目录
+func (r *Reader) Catalog() ([]Chapter, error) {
+	if r.content == nil {
+		return nil, ErrNoCatalog
+	}
+	return r.catalogAt(r.url, r.content.Index)
+}
+
+// CatalogOf 按缓存的章节解析所在书的目录，不需要先读取章节
+func (r *Reader) CatalogOf(chapter CachedChapter) ([]Chapter, error) {
+	return r.catalogAt(chapter.URL, chapter.Result.Index)
+}
+
+// catalogAt 解析地址为 url、导航链接为 index 的章节所在书的目录
+func (r *Reader) catalogAt(url string, index IndexResult) ([]Chapter, error) {
+	p, ok := r.parser.(CatalogParser)
+	if !ok {
+		return nil, ErrNoCatalog
+	}
+	catalog := ""
+	if index.Catalog != "" {
+		catalog = resolveURL(url, index.Catalog)
+	}
+	if _, plain := r.parser.(*PlainTextParser); plain {
+		catalog = url
+	}
+	return p.ParseCatalog(catalog)
+}
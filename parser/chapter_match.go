@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// chapterNumberPattern 章节标题中的序号：“第一百零二章”“第102节”“Chapter 102”
+	chapterNumberPattern = regexp.MustCompile(`(?i)第\s*([0-9零〇一二两三四五六七八九十百千万]+)\s*[章节回]|chapter\s*(\d+)`)
+	// leadingNumberPattern 以序号开头的标题：“102 出山”“102、出山”“一百零二 出山”
+	leadingNumberPattern = regexp.MustCompile(`^([0-9]+|[零〇一二两三四五六七八九十百千万]+)(?:[\s.、:：]+|$)`)
+	// chapterNotePattern 标题后的括号说明，例如“（求月票）”“【二更】”
+	chapterNotePattern = regexp.MustCompile(`[（(【\[][^）)】\]]*[）)】\]]`)
+	// volumePattern 卷名，只看章的序号
+	volumePattern = regexp.MustCompile(`第\s*[0-9零〇一二两三四五六七八九十百千万]+\s*[卷部集篇]`)
+)
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// chineseUnits 中文数字的单位
+var chineseUnits = map[rune]int{'十': 10, '百': 100, '千': 1000, '万': 10000}
+
+// ParseChineseNumber 把“一百零二”“一零二”“十二”或阿拉伯数字转换为整数
+func ParseChineseNumber(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+
+	hasUnit := false
+	for _, r := range s {
+		if _, ok := chineseUnits[r]; ok {
+			hasUnit = true
+		} else if _, ok := chineseDigits[r]; !ok {
+			return 0, false
+		}
+	}
+	if !hasUnit {
+		// 逐位读的数字，例如“一零二”
+		n := 0
+		for _, r := range s {
+			n = n*10 + chineseDigits[r]
+		}
+		return n, true
+	}
+
+	total, section, digit := 0, 0, 0
+	for _, r := range s {
+		if d, ok := chineseDigits[r]; ok {
+			digit = d
+			continue
+		}
+		unit := chineseUnits[r]
+		if unit == 10000 {
+			total += (section + digit) * unit
+			section, digit = 0, 0
+			continue
+		}
+		if digit == 0 {
+			// “十二”省略了“一”
+			digit = 1
+		}
+		section += digit * unit
+		digit = 0
+	}
+	return total + section + digit, true
+}
+
+// ChapterKey 归一化后的章节标题，用于在不同书源的目录中对应章节
+type ChapterKey struct {
+	Number int    // 章的序号，0 表示没有
+	Name   string // 去掉序号、括号说明、标点和空白后的章节名
+}
+
+// NormalizeChapterTitle 归一化章节标题：全角数字转为半角，中文序号转为数字
+func NormalizeChapterTitle(title string) ChapterKey {
+	title = strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, title)
+	title = volumePattern.ReplaceAllString(title, " ")
+	title = chapterNotePattern.ReplaceAllString(title, " ")
+
+	var key ChapterKey
+	if loc := chapterNumberPattern.FindStringSubmatchIndex(title); loc != nil {
+		var number string
+		if loc[2] >= 0 {
+			number = title[loc[2]:loc[3]]
+		} else {
+			number = title[loc[4]:loc[5]]
+		}
+		key.Number, _ = ParseChineseNumber(number)
+		title = title[:loc[0]] + " " + title[loc[1]:]
+	} else if match := leadingNumberPattern.FindStringSubmatch(strings.TrimSpace(title)); match != nil {
+		key.Number, _ = ParseChineseNumber(match[1])
+		title = strings.TrimSpace(title)[len(match[0]):]
+	}
+
+	key.Name = strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, title))
+	return key
+}
+
+// bigrams 相邻两个字组成的片段
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	grams := map[string]int{}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// similarity 两个章节名的相似度，0 到 1
+func similarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ga, gb := bigrams(a), bigrams(b)
+	if len(ga) == 0 || len(gb) == 0 {
+		return 0
+	}
+	common, total := 0, 0
+	for gram, n := range ga {
+		common += min(n, gb[gram])
+		total += n
+	}
+	for _, n := range gb {
+		total += n
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+// minNameSimilarity 两边都有章节名时，名字至少这样相似序号相同才算数，
+// 否则各卷重新编号时会选中别卷中序号相同的章节
+const minNameSimilarity = 0.5
+
+// matchScore 两个章节标题的匹配程度，序号相同、名字相似时最高
+func matchScore(a ChapterKey, b ChapterKey) float64 {
+	score := 0.0
+	if a.Name != "" || b.Name != "" {
+		score = similarity(a.Name, b.Name)
+	}
+	if a.Number > 0 && b.Number > 0 {
+		if a.Number == b.Number {
+			if a.Name == "" || b.Name == "" || score >= minNameSimilarity {
+				score += 1
+			}
+		} else {
+			score -= 0.3
+		}
+	}
+	return score
+}
+
+// minMatchScore 认为是同一章的最低分：序号相同且名字不冲突，或名字相同而序号不同，或没有序号时名字足够相似
+const minMatchScore = 0.7
+
+// MatchChapter 在另一个书源的目录中查找标题为 title 的章节，返回下标，找不到时返回 -1。
+// 分数相同时（例如各卷重新编号）选离 hint 最近的
+func MatchChapter(chapters []Chapter, title string, hint int) int {
+	key := NormalizeChapterTitle(title)
+	best, bestScore := -1, 0.0
+	for i, chapter := range chapters {
+		score := matchScore(key, NormalizeChapterTitle(chapter.Title))
+		if score < minMatchScore {
+			continue
+		}
+		if best < 0 || score > bestScore || score == bestScore && abs(i-hint) < abs(best-hint) {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package parser
+
+import "testing"
+
+func TestParseChineseNumber(t *testing.T) {
+	tests := map[string]int{
+		"102":    102,
+		"十":      10,
+		"十二":     12,
+		"二十":     20,
+		"一百零二":   102,
+		"两百":     200,
+		"一千零五":   1005,
+		"一万二千":   12000,
+		"一零二":    102,
+		"三千二百一十": 3210,
+	}
+	for s, want := range tests {
+		if got, ok := ParseChineseNumber(s); !ok || got != want {
+			t.Errorf("ParseChineseNumber(%q) = %d, %v, want %d", s, got, ok, want)
+		}
+	}
+	if _, ok := ParseChineseNumber("出山"); ok {
+		t.Error("不是数字时应返回 false")
+	}
+}
+
+func TestNormalizeChapterTitle(t *testing.T) {
+	tests := map[string]ChapterKey{
+		"第一百零二章 出山":           {102, "出山"},
+		"第102章　出山（求月票）":       {102, "出山"},
+		"第１０２章:出山":            {102, "出山"},
+		"第三卷 第一百零二章 出山":       {102, "出山"},
+		"102、出山":              {102, "出山"},
+		"Chapter 12 The Road": {12, "theroad"},
+		"楔子":                  {0, "楔子"},
+	}
+	for title, want := range tests {
+		if got := NormalizeChapterTitle(title); got != want {
+			t.Errorf("NormalizeChapterTitle(%q) = %+v, want %+v", title, got, want)
+		}
+	}
+}
+
+func TestMatchChapter(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "楔子"},
+		{Title: "第1章 少年出山"},
+		{Title: "第2章 下山"},
+		{Title: "第3章 初入江湖【二更】"},
+		{Title: "第二卷 第1章 重逢"},
+	}
+	tests := []struct {
+		title string
+		hint  int
+		want  int
+	}{
+		{"楔子", 0, 0},
+		{"第二章 下山", 0, 2},
+		{"第三章 初入江湖", 0, 3},
+		{"第2章 下山了", 0, 2},
+		{"第一章 少年出山", 0, 1},
+		{"第一章 重逢", 4, 4},
+		{"第九章 下山", 0, 2}, // 名字相同、序号不同
+		{"第九章 风起云涌", 0, -1},
+		{"少年出山啦", 0, 1}, // 没有序号时按名字相似度
+		{"江湖", 0, -1},
+	}
+	for _, tt := range tests {
+		if got := MatchChapter(chapters, tt.title, tt.hint); got != tt.want {
+			t.Errorf("MatchChapter(%q, %d) = %d, want %d", tt.title, tt.hint, got, tt.want)
+		}
+	}
+}
+
+func TestMatchChapterVolumeNumbering(t *testing.T) {
+	// 当前书源各卷重新编号，另一个书源连续编号
+	chapters := []Chapter{
+		{Title: "第1章 开端"},
+		{Title: "第2章 出发"},
+		{Title: "第101章 新生"},
+		{Title: "第102章 归来"},
+	}
+	if got := MatchChapter(chapters, "第1章 新生", 0); got != 2 {
+		t.Errorf("MatchChapter = %d, want 2（名字不同时序号相同不算数）", got)
+	}
+	if got := MatchChapter(chapters, "第1章", 2); got != 0 {
+		t.Errorf("MatchChapter = %d, want 0（没有章节名时按序号）", got)
+	}
+}
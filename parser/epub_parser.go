@@ -0,0 +1,325 @@
+package parser
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// epubTOCPattern 标记为目录的页面，例如 <nav epub:type="toc">
+var epubTOCPattern = regexp.MustCompile(`epub:type\s*=\s*["'][^"']*\btoc\b`)
+
+// IsEPUB 判断地址是否为本地 EPUB 文件，可以带 #序号 指定章节
+func IsEPUB(url string) bool {
+	file, _, _ := strings.Cut(url, "#")
+	return !strings.HasPrefix(url, "http") && strings.HasSuffix(strings.ToLower(file), ".epub")
+}
+
+// epubChapter EPUB 中的一章
+type epubChapter struct {
+	title   string
+	content string
+}
+
+// epubBook 解析后的 EPUB
+type epubBook struct {
+	title    string
+	author   string
+	chapters []epubChapter
+}
+
+// EPUBParser 读取本地 EPUB 文件，spine 中有正文且不是目录的每个文件作为一章，
+// 章节地址为“文件路径#序号”，序号从 1 开始
+type EPUBParser struct {
+	mu   sync.Mutex
+	path string
+	book *epubBook
+}
+
+// NewEPUBParser 创建 EPUB 解析器
+func NewEPUBParser() *EPUBParser {
+	return &EPUBParser{}
+}
+
+// load 读取并缓存 EPUB 文件
+func (p *EPUBParser) load(file string) (*epubBook, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.book != nil && p.path == file {
+		return p.book, nil
+	}
+	book, err := readEPUB(file)
+	if err != nil {
+		return nil, err
+	}
+	p.path, p.book = file, book
+	return book, nil
+}
+
+// chapterURL 第 n 章的地址
+func chapterURL(file string, n int) string {
+	return file + "#" + strconv.Itoa(n)
+}
+
+// ParseNovel 实现 IParser，没有指定序号时读取第一章
+func (p *EPUBParser) ParseNovel(url string) (NovelResult, error) {
+	file, fragment, _ := strings.Cut(url, "#")
+	book, err := p.load(file)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	n := 1
+	if fragment != "" {
+		if n, err = strconv.Atoi(fragment); err != nil {
+			return NovelResult{}, fmt.Errorf("章节序号无效: %s", fragment)
+		}
+	}
+	if n < 1 || n > len(book.chapters) {
+		return NovelResult{}, fmt.Errorf("%s 中没有第 %d 章", path.Base(file), n)
+	}
+
+	chapter := book.chapters[n-1]
+	result := NovelResult{
+		Content:   chapter.content,
+		Title:     chapter.title,
+		BookTitle: book.title,
+		Author:    book.author,
+		Index:     IndexResult{Catalog: file},
+	}
+	if n < len(book.chapters) {
+		result.Index.Next = chapterURL(file, n+1)
+		result.Index.NextChapter = result.Index.Next
+	}
+	if n > 1 {
+		result.Index.Prev = chapterURL(file, n-1)
+		result.Index.PrevChapter = result.Index.Prev
+	}
+	return result, nil
+}
+
+// ParseCatalog 实现 CatalogParser
+func (p *EPUBParser) ParseCatalog(url string) ([]Chapter, error) {
+	file, _, _ := strings.Cut(url, "#")
+	if file == "" {
+		return nil, ErrNoCatalog
+	}
+	book, err := p.load(file)
+	if err != nil {
+		return nil, err
+	}
+	chapters := make([]Chapter, len(book.chapters))
+	for i, chapter := range book.chapters {
+		chapters[i] = Chapter{Title: chapter.title, URL: chapterURL(file, i+1)}
+	}
+	return chapters, nil
+}
+
+// opfPackage content.opf 中用到的部分
+type opfPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Creator  []string `xml:"metadata>creator"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Toc   string `xml:"toc,attr"`
+		Items []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// ncxPoint toc.ncx 中的一项
+type ncxPoint struct {
+	Label  string     `xml:"navLabel>text"`
+	Src    string     `xml:"content>src,attr"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+// readEPUB 读取 EPUB 的书名、作者和各章正文
+func readEPUB(file string) (*epubBook, error) {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	files := map[string]*zip.File{}
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("EPUB 中没有 %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	data, err := read("META-INF/container.xml")
+	if err != nil {
+		return nil, err
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("EPUB 中没有 content.opf")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	if data, err = read(opfPath); err != nil {
+		return nil, err
+	}
+	var opf opfPackage
+	if err := xml.Unmarshal(data, &opf); err != nil {
+		return nil, err
+	}
+
+	// 文件路径相对于 content.opf 所在目录
+	base := path.Dir(opfPath)
+	resolve := func(dir string, href string) string {
+		href, _, _ = strings.Cut(href, "#")
+		return path.Clean(path.Join(dir, href))
+	}
+
+	book := &epubBook{}
+	if len(opf.Title) > 0 {
+		book.title = strings.TrimSpace(opf.Title[0])
+	}
+	if len(opf.Creator) > 0 {
+		book.author = strings.TrimSpace(opf.Creator[0])
+	}
+
+	// 章节名优先使用 EPUB 3 的 nav，其次为 toc.ncx
+	titles := map[string]string{}
+	items := map[string]string{}
+	navs := map[string]bool{}
+	for _, item := range opf.Manifest {
+		href := resolve(base, item.Href)
+		items[item.ID] = href
+		if slices.Contains(strings.Fields(item.Properties), "nav") {
+			navs[href] = true
+			if data, err := read(href); err == nil {
+				navTitles(data, path.Dir(href), resolve, titles)
+			}
+		}
+	}
+	if ncx, ok := items[opf.Spine.Toc]; ok && len(titles) == 0 {
+		if data, err := read(ncx); err == nil {
+			var toc struct {
+				Points []ncxPoint `xml:"navMap>navPoint"`
+			}
+			if xml.Unmarshal(data, &toc) == nil {
+				ncxTitles(toc.Points, path.Dir(ncx), resolve, titles)
+			}
+		}
+	}
+
+	for _, itemref := range opf.Spine.Items {
+		href, ok := items[itemref.IDRef]
+		if !ok || navs[href] || itemref.Linear == "no" {
+			// 目录页和不在正文顺序中的页面
+			continue
+		}
+		data, err := read(href)
+		if err != nil || epubTOCPattern.Match(data) {
+			continue
+		}
+		title, content := xhtmlText(data)
+		if content == "" {
+			// 封面、插图等没有正文的页面
+			continue
+		}
+		if t, ok := titles[href]; ok {
+			title = t
+		}
+		if title == "" {
+			title = fmt.Sprintf("第%d章", len(book.chapters)+1)
+		}
+		book.chapters = append(book.chapters, epubChapter{title: title, content: content})
+	}
+	if len(book.chapters) == 0 {
+		return nil, errors.New("EPUB 中没有正文")
+	}
+	return book, nil
+}
+
+// navTitles 读取 nav 文档中各文件的章节名，同一文件取第一个
+func navTitles(data []byte, dir string, resolve func(string, string) string, titles map[string]string) {
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		return
+	}
+	document.Find("nav a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		file := resolve(dir, href)
+		if _, ok := titles[file]; !ok {
+			titles[file] = strings.TrimSpace(a.Text())
+		}
+	})
+}
+
+// ncxTitles 读取 toc.ncx 中各文件的章节名，同一文件取第一个
+func ncxTitles(points []ncxPoint, dir string, resolve func(string, string) string, titles map[string]string) {
+	for _, point := range points {
+		file := resolve(dir, point.Src)
+		if _, ok := titles[file]; !ok {
+			titles[file] = strings.TrimSpace(point.Label)
+		}
+		ncxTitles(point.Points, dir, resolve, titles)
+	}
+}
+
+// xhtmlText 提取章节文件的标题和正文，正文每段一行
+func xhtmlText(data []byte) (string, string) {
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		return "", ""
+	}
+	title := strings.TrimSpace(document.Find("h1, h2, h3").First().Text())
+	if title == "" {
+		title = strings.TrimSpace(document.Find("title").Text())
+	}
+
+	var paragraphs []string
+	add := func(text string) {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				paragraphs = append(paragraphs, line)
+			}
+		}
+	}
+	if p := document.Find("body p"); p.Length() > 0 {
+		p.Each(func(_ int, s *goquery.Selection) {
+			add(s.Text())
+		})
+	} else {
+		body := document.Find("body")
+		body.Find("h1, h2, h3").First().Remove()
+		add(body.Text())
+	}
+	return title, strings.Join(paragraphs, "\n")
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"novel-reader-go/utils"
+)
+
+func TestEPUBParser(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = utils.WriteEPUB(f, utils.EPUBBook{
+		ID:       "urn:test",
+		Title:    "某某小说",
+		Author:   "张三",
+		Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Chapters: []utils.EPUBChapter{
+			{Title: "第一章 出山", Paragraphs: []string{"　　第一段", "第二段 <b>"}},
+			{Title: "第二章 下山", Paragraphs: []string{"第三段"}},
+		},
+	})
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEPUB(file+"#2") || IsEPUB("http://example.com/a.epub") {
+		t.Error("IsEPUB 判断错误")
+	}
+	r := NewReaderUrlWithClient(file, nil)
+	result, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Title != "第一章 出山" || result.BookTitle != "某某小说" || result.Author != "张三" {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Content != "第一段\n第二段 <b>" {
+		t.Errorf("Content = %q", result.Content)
+	}
+	if r.PrevURL() != "" || r.NextURL() != file+"#2" {
+		t.Errorf("PrevURL = %q, NextURL = %q", r.PrevURL(), r.NextURL())
+	}
+
+	result, err = r.ReadNextChapter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Title != "第二章 下山" || result.Content != "第三段" || r.NextURL() != "" {
+		t.Errorf("result = %+v", result)
+	}
+
+	chapters, err := r.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chapter{{Title: "第一章 出山", URL: file + "#1"}, {Title: "第二章 下山", URL: file + "#2"}}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("Catalog = %+v", chapters)
+	}
+}
+
+// writeZip 按文件名和内容写入 zip
+func writeZip(t *testing.T, file string, files [][2]string) {
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	z := zip.NewWriter(f)
+	for _, entry := range files {
+		w, err := z.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry[1]))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEPUBTOCPage(t *testing.T) {
+	xhtml := func(body string) string {
+		return `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>` + body + `</body></html>`
+	}
+	var list strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&list, "<p>第%d章 章节%d</p>", i, i)
+	}
+
+	file := filepath.Join(t.TempDir(), "book.epub")
+	writeZip(t, file, [][2]string{
+		{"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`},
+		{"OEBPS/content.opf", `<package><metadata><title>某某小说</title></metadata>
+<manifest>
+  <item id="nav" href="nav.xhtml" properties="nav"/>
+  <item id="toc" href="toc.xhtml"/>
+  <item id="contents" href="contents.xhtml"/>
+  <item id="notes" href="notes.xhtml"/>
+  <item id="c1" href="c1.xhtml"/>
+  <item id="c2" href="c2.xhtml"/>
+</manifest>
+<spine>
+  <itemref idref="nav"/>
+  <itemref idref="toc"/>
+  <itemref idref="contents"/>
+  <itemref idref="notes" linear="no"/>
+  <itemref idref="c1"/>
+  <itemref idref="c2"/>
+</spine></package>`},
+		{"OEBPS/nav.xhtml", xhtml(`<nav epub:type="toc"><ol><li><a href="c1.xhtml">第一章 出山</a></li><li><a href="c2.xhtml">第二章 下山</a></li></ol></nav>`)},
+		{"OEBPS/toc.xhtml", xhtml(`<section epub:type="toc"><h1>目录</h1>` + list.String() + `</section>`)},
+		{"OEBPS/contents.xhtml", xhtml(`<h1>目录</h1>` + list.String())},
+		{"OEBPS/notes.xhtml", xhtml(`<p>注释</p>`)},
+		{"OEBPS/c1.xhtml", xhtml(`<h1>第一章 出山</h1><p>第一段</p>`)},
+		{"OEBPS/c2.xhtml", xhtml(`<h1>第二章 下山</h1><p>第二段</p>`)},
+	})
+
+	r := NewReaderUrlWithClient(file, nil)
+	r.SetCache(NewChapterCache(t.TempDir()))
+	chapters, err := NewEPUBParser().ParseCatalog(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, chapter := range chapters {
+		titles = append(titles, chapter.Title)
+	}
+	// nav、epub:type="toc" 和 linear="no" 的页面不是章节，没有标记的目录页仍按顺序阅读
+	if want := []string{"目录", "第一章 出山", "第二章 下山"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("章节 = %q, want %q", titles, want)
+	}
+
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.ReadNext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.EndOfBook || result.Title != "第一章 出山" {
+		t.Errorf("目录页后应继续读到第一章，实际 %+v", result)
+	}
+	if _, ok := r.cache.Get(file); !ok {
+		t.Error("书中的目录页也应缓存")
+	}
+}
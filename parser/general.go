@@ -15,15 +15,21 @@ type IParser interface {
 
 // NovelResult 包含解析结果
 type NovelResult struct {
-	Index   IndexResult
-	Content string
-	Title   string
+	Index     IndexResult
+	Content   string
+	Title     string // 章节标题
+	BookTitle string // 书名
+	Author    string // 作者
+	EndOfBook bool   // 已是最新章节，Content 为当前章节内容
 }
 
 // IndexResult 包含上一章和下一章链接
 type IndexResult struct {
-	Next string
-	Prev string
+	Next        string // 向后阅读的链接，优先下一页，其次下一章
+	Prev        string // 向前阅读的链接，优先上一页，其次上一章
+	NextChapter string
+	PrevChapter string
+	Catalog     string // 目录页
 }
 
 // GeneralParser 实现 IParser 接口
@@ -45,28 +51,14 @@ func (p *GeneralParser) fetchUrl(url string) (string, error) {
 	return string(body), nil
 }
 
-// handleTextNodes 处理文本节点，类似于原 TypeScript 版本的功能
-// handleTextNodes 处理文本节点，与 TypeScript 版本功能一致
-// handleTextNodes 处理文本节点，与 TypeScript 版本功能一致
+// handleTextNodes 将正文节点渲染为带缩进的段落，
+// 支持 br 分段以及 div、span、blockquote、标题等嵌套标签
 func (p *GeneralParser) handleTextNodes(selection *goquery.Selection) string {
-	var lines []string
-	const indent = "    " // 4个空格的缩进
-
-	selection.Contents().Each(func(i int, s *goquery.Selection) {
-		// 处理文本节点
-		if goquery.NodeName(s) == "#text" {
-			content := strings.TrimSpace(s.Text())
-			if content != "" {
-				lines = append(lines, indent+content)
-			}
-		} else if goquery.NodeName(s) == "p" { // 注意：else if 必须与上一个 } 在同一行
-			content := strings.TrimSpace(s.Text())
-			if content != "" {
-				lines = append(lines, indent+content)
-			}
-		}
-	})
-
+	paragraphs := renderParagraphs(selection)
+	lines := make([]string, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		lines = append(lines, paragraphIndent+paragraph)
+	}
 	return strings.Join(lines, "\n")
 }
 
@@ -79,9 +71,18 @@ func (p *GeneralParser) parseContent(doc string) (string, error) {
 		return "", err
 	}
 
+	node := p.findContentNode(document)
+	if node == nil {
+		return "", nil
+	}
+	return p.handleTextNodes(node), nil
+}
+
+// findContentNode 查找正文所在节点，找不到时返回 nil
+func (p *GeneralParser) findContentNode(document *goquery.Document) *goquery.Selection {
 	// 递归解析子节点
-	var parseChildren func(parent *goquery.Selection, size int, slope float64, variance float64) string
-	parseChildren = func(parent *goquery.Selection, size int, slope float64, variance float64) string {
+	var parseChildren func(parent *goquery.Selection, size int, slope float64, variance float64) *goquery.Selection
+	parseChildren = func(parent *goquery.Selection, size int, slope float64, variance float64) *goquery.Selection {
 		children := parent.Children()
 		if children.Length() == 1 {
 			return parseChildren(children.First(), size, slope, variance)
@@ -130,16 +131,16 @@ func (p *GeneralParser) parseContent(doc string) (string, error) {
 			if tempVariance > 100 {
 				return parseChildren(maxChildren.element, maxChildren.size, maxChildren.slope, tempVariance)
 			} else {
-				return p.handleTextNodes(parent)
+				return parent
 			}
 		}
-		return ""
+		return nil
 	}
 
 	body := document.Find("body")
 	body.Find("style, script").Remove()
 	bodyText := body.Text()
-	return parseChildren(body, utf8.RuneCountInString(bodyText), 1.0, float64(utf8.RuneCountInString(bodyText))), nil
+	return parseChildren(body, utf8.RuneCountInString(bodyText), 1.0, float64(utf8.RuneCountInString(bodyText)))
 }
 
 // parseIndexChapter 解析章节索引
@@ -150,57 +151,99 @@ func (p *GeneralParser) parseIndexChapter(doc string) (IndexResult, error) {
 		return IndexResult{}, err
 	}
 
-	nextHref, err := p.parseNextPage(document)
-	if err != nil {
-		return IndexResult{}, err
+	links := p.parseNavigation(document)
+
+	result := IndexResult{
+		NextChapter: links.first(linkNextChapter, linkNext),
+		PrevChapter: links.first(linkPrevChapter, linkPrev),
+		Catalog:     links[linkCatalog],
+	}
+	// 章节内分页时优先翻到下一页
+	result.Next = links.first(linkNextPage)
+	if result.Next == "" {
+		result.Next = result.NextChapter
 	}
+	result.Prev = links.first(linkPrevPage)
+	if result.Prev == "" {
+		result.Prev = result.PrevChapter
+	}
+	return result, nil
+}
 
-	prevHref, err := p.parsePrevPage(document)
+// titleInfo 包含章节标题、书名和作者
+type titleInfo struct {
+	Chapter string
+	Book    string
+	Author  string
+}
+
+// parseTitle 解析章节标题、书名和作者
+func (p *GeneralParser) parseTitle(doc string) (titleInfo, error) {
+	reader := strings.NewReader(doc)
+	document, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
-		return IndexResult{}, err
+		return titleInfo{}, err
 	}
 
-	return IndexResult{Next: nextHref, Prev: prevHref}, nil
-}
+	parts := splitPageTitle(document.Find("title").Text())
 
-// parsePrevPage 解析上一页链接
-func (p *GeneralParser) parsePrevPage(document *goquery.Document) (string, error) {
-	prevHref := ""
-	document.Find("body").Find("a").Each(func(i int, s *goquery.Selection) {
-		text := strings.TrimSpace(s.Text())
-		if text == "上一章" || text == "上一页" {
-			href, exists := s.Attr("href")
-			if exists {
-				prevHref = href
-			}
+	info := titleInfo{}
+	info.Chapter = p.findChapterHeading(document)
+	if info.Chapter == "" && len(parts) > 0 {
+		info.Chapter = parts[0]
+	}
+
+	info.Book = metaContent(document, "og:novel:book_name")
+	if info.Book == "" {
+		// 章节页的 og:title 通常是章节标题，不能当作书名
+		ogTitle := metaContent(document, "og:title")
+		if ogTitle != info.Chapter && !chapterTitlePattern.MatchString(ogTitle) {
+			info.Book = ogTitle
 		}
-	})
-	return prevHref, nil
+	}
+	if info.Book == "" && len(parts) >= 3 {
+		info.Book = parts[1]
+	}
+	info.Book = cleanBookTitle(info.Book)
+
+	info.Author = metaContent(document, "og:novel:author", "author")
+	if info.Author == "" {
+		info.Author = findAuthor(document)
+	}
+	return info, nil
 }
 
-// parseNextPage 解析下一页链接
-func (p *GeneralParser) parseNextPage(document *goquery.Document) (string, error) {
-	nextHref := ""
-	document.Find("body").Find("a").Each(func(i int, s *goquery.Selection) {
-		text := strings.TrimSpace(s.Text())
-		if text == "下一章" || text == "下一页" {
-			href, exists := s.Attr("href")
-			if exists {
-				nextHref = href
+// findChapterHeading 从正文节点向上查找最近的标题元素
+func (p *GeneralParser) findChapterHeading(document *goquery.Document) string {
+	node := p.findContentNode(document)
+	if node == nil {
+		node = document.Find("body")
+	}
+
+	fallback := ""
+	for sel := node; sel.Length() > 0; sel = sel.Parent() {
+		for _, tag := range []string{"h1", "h2", "h3"} {
+			found := ""
+			sel.Find(tag).EachWithBreak(func(i int, s *goquery.Selection) bool {
+				text := normalizeSpace(s.Text())
+				if text == "" {
+					return true
+				}
+				if chapterTitlePattern.MatchString(text) {
+					found = text
+					return false
+				}
+				if fallback == "" {
+					fallback = text
+				}
+				return true
+			})
+			if found != "" {
+				return found
 			}
 		}
-	})
-	return nextHref, nil
-}
-
-// parseTitle 解析标题
-func (p *GeneralParser) parseTitle(doc string) (string, error) {
-	reader := strings.NewReader(doc)
-	document, err := goquery.NewDocumentFromReader(reader)
-	if err != nil {
-		return "", err
 	}
-	return document.Find("title").Text(), nil
+	return fallback
 }
 
 // ParseNovel 解析小说内容
@@ -226,8 +269,10 @@ func (p *GeneralParser) ParseNovel(url string) (NovelResult, error) {
 	}
 
 	return NovelResult{
-		Index:   index,
-		Content: content,
-		Title:   title,
+		Index:     index,
+		Content:   content,
+		Title:     title.Chapter,
+		BookTitle: title.Book,
+		Author:    title.Author,
 	}, nil
 }
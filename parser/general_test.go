@@ -2,7 +2,10 @@ package parser
 
 import (
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func TestParseContentWithRealData(t *testing.T) {
@@ -28,3 +31,220 @@ func TestParseContentWithRealData(t *testing.T) {
 		t.Errorf("解析结果与预期不符\n期望: %s\n实际: %s", expected, actual)
 	}
 }
+
+func TestHandleTextNodes(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "br分段",
+			html:     `<div id="c">第一段<br><br>&nbsp;&nbsp;第二段<br/>第三段</div>`,
+			expected: "    第一段\n    第二段\n    第三段",
+		},
+		{
+			name:     "嵌套标签",
+			html:     `<div id="c"><div><span>第一</span><font>段</font></div><p>第二段</p><blockquote>引用</blockquote></div>`,
+			expected: "    第一段\n    第二段\n    引用",
+		},
+		{
+			name:     "标题与脚本",
+			html:     `<div id="c"><h2>第一章</h2><script>var a = 1;</script>　　正文<a href="/next">下一章</a></div>`,
+			expected: "    第一章\n    正文",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(c.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := GeneralParser{}
+			actual := p.handleTextNodes(doc.Find("#c"))
+			if actual != c.expected {
+				t.Errorf("解析结果与预期不符\n期望: %q\n实际: %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeSpace(t *testing.T) {
+	cases := map[string]string{
+		"他　说":               "他说",
+		"他\n  说道：":          "他说道：",
+		"第十章 出山":            "第十章 出山",
+		"第十章\u00a0出山":       "第十章 出山",
+		"Chapter\n10":       "Chapter 10",
+		"  hello 　 world  ": "hello world",
+		"他说\n「hello」":       "他说「hello」",
+	}
+	for input, expected := range cases {
+		if actual := normalizeSpace(input); actual != expected {
+			t.Errorf("normalizeSpace(%q) = %q，期望 %q", input, actual, expected)
+		}
+	}
+}
+
+func TestParseTitle(t *testing.T) {
+	doc := `<html><head><title>第十章 出山_某某小说最新章节_笔趣阁手机版</title></head>
+<body>
+<div class="header"><h1>笔趣阁</h1></div>
+<div class="book"><h1>第十章 出山</h1><p>作者：张三</p>
+<div id="content">正文第一段<br>正文第二段</div></div>
+</body></html>`
+
+	p := GeneralParser{}
+	info, err := p.parseTitle(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := titleInfo{Chapter: "第十章 出山", Book: "某某小说", Author: "张三"}
+	if info != expected {
+		t.Errorf("解析结果与预期不符\n期望: %+v\n实际: %+v", expected, info)
+	}
+}
+
+func TestParseTitleOGChapter(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name: "og:title为章节标题",
+			html: `<html><head><meta property="og:title" content="第十章 出山">
+<title>第十章 出山_某某小说_笔趣阁</title></head>
+<body><h1>第十章 出山</h1><div id="content">正文</div></body></html>`,
+			expected: "某某小说",
+		},
+		{
+			name: "og:title为书名",
+			html: `<html><head><meta property="og:title" content="某某小说"><title>出山</title></head>
+<body><h1>出山</h1><div id="content">正文</div></body></html>`,
+			expected: "某某小说",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := GeneralParser{}
+			info, err := p.parseTitle(c.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Book != c.expected {
+				t.Errorf("书名与预期不符\n期望: %q\n实际: %q", c.expected, info.Book)
+			}
+		})
+	}
+}
+
+func TestParseIndexChapter(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		expected IndexResult
+	}{
+		{
+			name: "章节内分页",
+			html: `<body>
+<a href="/1/1.html">上一章</a><a href="/1/">章节目录</a>
+<a href="/1/2_2.html">下一页→</a><a href="/1/3.html">下一章</a></body>`,
+			expected: IndexResult{Next: "/1/2_2.html", Prev: "/1/1.html", NextChapter: "/1/3.html", PrevChapter: "/1/1.html", Catalog: "/1/"},
+		},
+		{
+			name: "rel属性与英文",
+			html: `<head><link rel="next" href="/c/3"><link rel="prev" href="/c/1"></head>
+<body><a href="/c/">Table of Contents</a><a href="/c/9">Next Chapter</a></body>`,
+			expected: IndexResult{Next: "/c/9", Prev: "/c/1", NextChapter: "/c/9", PrevChapter: "/c/1", Catalog: "/c/"},
+		},
+		{
+			name: "翻页脚本与箭头",
+			html: `<body><a href="javascript:void(0)">下一章</a><a href="/b/1.html">←</a>
+<script>var preview_page = "/b/1.html"; var next_page = "/b/3.html"; var index_page = "/b/";</script></body>`,
+			expected: IndexResult{Next: "/b/3.html", Prev: "/b/1.html", NextChapter: "/b/3.html", PrevChapter: "/b/1.html", Catalog: "/b/"},
+		},
+		{
+			name:     "id和class",
+			html:     `<body><a id="pb_prev" href="/d/1">◀</a><a class="btn-next" href="/d/3">▶</a></body>`,
+			expected: IndexResult{Next: "/d/3", Prev: "/d/1", NextChapter: "/d/3", PrevChapter: "/d/1"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := GeneralParser{}
+			actual, err := p.parseIndexChapter(c.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != c.expected {
+				t.Errorf("解析结果与预期不符\n期望: %+v\n实际: %+v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestClassifyNavText(t *testing.T) {
+	cases := []struct {
+		text string
+		kind linkKind
+		ok   bool
+	}{
+		{"Next »", linkNext, true},
+		{"next 2", linkNext, true},
+		{"Previous", linkPrev, true},
+		{"« Prev", linkPrev, true},
+		{"Next Page", linkNextPage, true},
+		{"Index", linkCatalog, true},
+		{"TOC", linkCatalog, true},
+		{"下一章：重逢", linkNextChapter, true},
+		{"目录(共100章)", linkCatalog, true},
+		{"Preview the book", 0, false},
+		{"index of authors", 0, false},
+		{"tocommend", 0, false},
+		{"Contents of this site", 0, false},
+		{"Nextdoor", 0, false},
+	}
+	for _, c := range cases {
+		kind, ok := classifyNavText(c.text)
+		if ok != c.ok || ok && kind != c.kind {
+			t.Errorf("classifyNavText(%q) = %v, %v，期望 %v, %v", c.text, kind, ok, c.kind, c.ok)
+		}
+	}
+}
+
+func TestParseCatalog(t *testing.T) {
+	client := stubHttpClient{
+		"https://www.example.com/book/1/": `<html><body>
+			<dl>
+				<dt>最新章节</dt>
+				<dd><a href="3.html">第三章 归来</a></dd>
+				<dd><a href="2.html">第二章 下山</a></dd>
+				<dt>正文</dt>
+				<dd><a href="1.html">第一章 出山</a></dd>
+				<dd><a href="2.html">第二章 下山</a></dd>
+				<dd><a href="/book/1/3.html">第三章 归来</a></dd>
+			</dl>
+			<a href="/">返回首页</a>
+		</body></html>`,
+	}
+
+	chapters, err := NewGeneralParser(client).ParseCatalog("https://www.example.com/book/1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"第一章 出山", "第二章 下山", "第三章 归来"}
+	if len(chapters) != len(expected) {
+		t.Fatalf("期望 %d 章，实际 %v", len(expected), chapters)
+	}
+	for i, title := range expected {
+		if chapters[i].Title != title {
+			t.Errorf("第 %d 章期望 %s，实际 %s", i+1, title, chapters[i].Title)
+		}
+	}
+	if i := ChapterIndex(chapters, "https://www.example.com/book/1/2.html"); i != 1 {
+		t.Errorf("第二章的位置应为 1，实际 %d", i)
+	}
+}
@@ -2,8 +2,12 @@ package parser
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
 
 	"golang.org/x/net/html/charset"
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -14,32 +18,137 @@ type HttpClient interface {
 	FetchUrl(url string) ([]byte, error)
 }
 
+// ContextFetcher 可以随 ctx 取消请求的HTTP客户端
+type ContextFetcher interface {
+	FetchUrlContext(ctx context.Context, url string) ([]byte, error)
+}
+
+// Downloader 可以下载文件的HTTP客户端，与 FetchUrl 不同，不转换编码
+type Downloader interface {
+	Download(url string) ([]byte, error)
+}
+
+// defaultUserAgent 默认的 User-Agent
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/87.0.4280.88 Safari/537.36"
+
+// HttpOptions 网络设置
+type HttpOptions struct {
+	Timeout   time.Duration
+	Retries   int    // 网络错误或服务器错误时的重试次数
+	Proxy     string // 代理地址，为空时使用环境变量
+	UserAgent string
+}
+
 // DefaultHttpClient 默认HTTP客户端实现
-type DefaultHttpClient struct{}
+type DefaultHttpClient struct {
+	client    *http.Client
+	retries   int
+	userAgent string
+}
 
 // NewDefaultHttpClient 创建默认HTTP客户端
 func NewDefaultHttpClient() *DefaultHttpClient {
 	return &DefaultHttpClient{}
 }
 
+// NewHttpClient 按网络设置创建HTTP客户端
+func NewHttpClient(options HttpOptions) (*DefaultHttpClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.Proxy != "" {
+		proxy, err := url.Parse(options.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址无效: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &DefaultHttpClient{
+		client:    &http.Client{Timeout: options.Timeout, Transport: transport},
+		retries:   options.Retries,
+		userAgent: options.UserAgent,
+	}, nil
+}
+
 // FetchUrl 实现HttpClient接口
 func (p *DefaultHttpClient) FetchUrl(url string) ([]byte, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	return p.FetchUrlContext(context.Background(), url)
+}
+
+// FetchUrlContext 实现 ContextFetcher，ctx 结束后不再重试
+func (p *DefaultHttpClient) FetchUrlContext(ctx context.Context, url string) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	for attempt := 0; attempt <= p.retries && ctx.Err() == nil; attempt++ {
+		body, err = p.fetch(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nil, err
+}
+
+// Download 实现 Downloader，出错时同样重试
+func (p *DefaultHttpClient) Download(url string) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		var resp *http.Response
+		body, resp, err = p.get(context.Background(), url)
+		if err == nil {
+			if resp.StatusCode >= 400 {
+				return nil, fmt.Errorf("%s: %s", url, resp.Status)
+			}
+			return body, nil
+		}
+	}
+	return nil, err
+}
+
+// get 请求一次，返回原始的响应体
+func (p *DefaultHttpClient) get(ctx context.Context, url string) ([]byte, *http.Response, error) {
+	client := p.client
+	if client == nil {
+		client = &http.Client{}
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/87.0.4280.88 Safari/537.36")
+	userAgent := p.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 500 {
+		return nil, nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
 	// 读取响应体
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp, nil
+}
+
+// fetch 请求一次并处理编码
+func (p *DefaultHttpClient) fetch(ctx context.Context, url string) ([]byte, error) {
+	body, resp, err := p.get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
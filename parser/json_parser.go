@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// JSONSource 描述一个以 JSON 返回章节的接口
+//
+// 路径字段为 JSONPath 表达式，例如 $.data.content；
+// 链接模板中的 {id} 会替换为 next/prev 取到的值（经过 URL 编码），
+// 模板为空时直接把取到的值当作链接
+type JSONSource struct {
+	Content     string `json:"content"`
+	Title       string `json:"title"`
+	BookTitle   string `json:"bookTitle"`
+	Author      string `json:"author"`
+	Next        string `json:"next"`
+	Prev        string `json:"prev"`
+	NextURL     string `json:"nextUrl"`
+	PrevURL     string `json:"prevUrl"`
+	ContentHTML bool   `json:"contentHtml"` // 正文是否为 HTML，为 false 时自动检测
+}
+
+// LoadJSONSource 从配置文件读取 JSONSource
+func LoadJSONSource(file string) (JSONSource, error) {
+	var source JSONSource
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return source, err
+	}
+	if err := json.Unmarshal(data, &source); err != nil {
+		return source, err
+	}
+	if source.Content == "" {
+		return source, fmt.Errorf("%s: 缺少 content 路径", file)
+	}
+	return source, nil
+}
+
+// htmlContentPattern 检测正文中是否包含段落标签
+var htmlContentPattern = regexp.MustCompile(`(?i)<\s*(p|br|div)\b`)
+
+// JSONParser 实现 IParser 接口，从 JSON 接口读取章节
+type JSONParser struct {
+	client HttpClient
+	source JSONSource
+}
+
+// NewJSONParser 创建新的 JSONParser 实例
+func NewJSONParser(client HttpClient, source JSONSource) *JSONParser {
+	return &JSONParser{client: client, source: source}
+}
+
+// ParseNovel 解析小说内容
+func (p *JSONParser) ParseNovel(url string) (NovelResult, error) {
+	body, err := p.client.FetchUrl(url)
+	if err != nil {
+		return NovelResult{}, err
+	}
+
+	data, err := decodeJSON(body)
+	if err != nil {
+		return NovelResult{}, fmt.Errorf("解析 JSON 失败: %w", err)
+	}
+
+	content, err := p.lookup(data, p.source.Content)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	title, err := p.lookup(data, p.source.Title)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	bookTitle, err := p.lookup(data, p.source.BookTitle)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	author, err := p.lookup(data, p.source.Author)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	next, err := p.lookup(data, p.source.Next)
+	if err != nil {
+		return NovelResult{}, err
+	}
+	prev, err := p.lookup(data, p.source.Prev)
+	if err != nil {
+		return NovelResult{}, err
+	}
+
+	return NovelResult{
+		Index: IndexResult{
+			Next:        expandURLTemplate(p.source.NextURL, next),
+			Prev:        expandURLTemplate(p.source.PrevURL, prev),
+			NextChapter: expandURLTemplate(p.source.NextURL, next),
+			PrevChapter: expandURLTemplate(p.source.PrevURL, prev),
+		},
+		Content:   p.formatContent(content),
+		Title:     strings.TrimSpace(title),
+		BookTitle: strings.TrimSpace(bookTitle),
+		Author:    strings.TrimSpace(author),
+	}, nil
+}
+
+// lookup 执行 JSONPath 并把结果拼接为字符串，路径为空时返回空字符串
+func (p *JSONParser) lookup(data interface{}, expr string) (string, error) {
+	if expr == "" {
+		return "", nil
+	}
+	values, err := evalJSONPath(data, expr)
+	if err != nil {
+		return "", err
+	}
+	return jsonString(values), nil
+}
+
+// formatContent 将正文整理为带缩进的段落
+func (p *JSONParser) formatContent(content string) string {
+	var paragraphs []string
+	if p.source.ContentHTML || htmlContentPattern.MatchString(content) {
+		document, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+		if err == nil {
+			paragraphs = renderParagraphs(document.Find("body"))
+		}
+	} else {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		for _, line := range strings.Split(content, "\n") {
+			if line = normalizeSpace(line); line != "" {
+				paragraphs = append(paragraphs, line)
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(paragraphs))
+	for _, paragraph := range paragraphs {
+		lines = append(lines, paragraphIndent+paragraph)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// expandURLTemplate 用 value 替换模板中的 {id}，value 为空或为 0 时表示没有链接
+func expandURLTemplate(template string, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" || value == "-1" {
+		return ""
+	}
+	if template == "" {
+		return value
+	}
+	return strings.ReplaceAll(template, "{id}", url.PathEscape(value))
+}
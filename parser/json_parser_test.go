@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// stubHttpClient 按 URL 返回预设的响应体
+type stubHttpClient map[string]string
+
+func (c stubHttpClient) FetchUrl(url string) ([]byte, error) {
+	return []byte(c[url]), nil
+}
+
+func TestJSONParser(t *testing.T) {
+	client := stubHttpClient{
+		"https://api.example.com/chapter/2": `{
+			"code": 0,
+			"data": {
+				"chapter": {"title": "第二章 下山", "content": "第一段\n\n　　第二段"},
+				"book": {"name": "某某小说", "authors": [{"name": "张三"}]},
+				"nextId": 12345678901,
+				"prevId": null
+			}
+		}`,
+	}
+	source := JSONSource{
+		Content:   "$.data.chapter.content",
+		Title:     "$.data.chapter.title",
+		BookTitle: "$['data']['book']['name']",
+		Author:    "$.data.book.authors[0].name",
+		Next:      "$.data.nextId",
+		Prev:      "$.data.prevId",
+		NextURL:   "https://api.example.com/chapter/{id}",
+		PrevURL:   "https://api.example.com/chapter/{id}",
+	}
+
+	result, err := NewJSONParser(client, source).ParseNovel("https://api.example.com/chapter/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := NovelResult{
+		Index: IndexResult{
+			Next:        "https://api.example.com/chapter/12345678901",
+			NextChapter: "https://api.example.com/chapter/12345678901",
+		},
+		Content:   "    第一段\n    第二段",
+		Title:     "第二章 下山",
+		BookTitle: "某某小说",
+		Author:    "张三",
+	}
+	if result != expected {
+		t.Errorf("解析结果与预期不符\n期望: %+v\n实际: %+v", expected, result)
+	}
+}
+
+func TestEvalJSONPath(t *testing.T) {
+	data := map[string]interface{}{
+		"list": []interface{}{"<p>甲</p>", "<p>乙</p>"},
+		"pages": map[string]interface{}{
+			"c": "丙", "a": "甲", "b": "乙", "e": "戊", "d": "丁",
+		},
+	}
+	cases := map[string]string{
+		"$.pages[*]": "甲\n乙\n丙\n丁\n戊",
+		"$.list[*]":  "<p>甲</p>\n<p>乙</p>",
+		"$.list[-1]": "<p>乙</p>",
+		"list[0]":    "<p>甲</p>",
+		"$.missing":  "",
+	}
+	for expr, expected := range cases {
+		values, err := evalJSONPath(data, expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := jsonString(values); actual != expected {
+			t.Errorf("%s: 期望 %q，实际 %q", expr, expected, actual)
+		}
+	}
+}
+
+func TestEvalJSONPathKeyOrder(t *testing.T) {
+	var body, want strings.Builder
+	body.WriteString(`{"chapters": {`)
+	for i := 1; i <= 12; i++ {
+		if i > 1 {
+			body.WriteString(",")
+			want.WriteString("\n")
+		}
+		fmt.Fprintf(&body, `"%d": {"title": "第%d章"}`, i, i)
+		fmt.Fprintf(&want, "第%d章", i)
+	}
+	body.WriteString(`}}`)
+
+	data, err := decodeJSON([]byte(body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := evalJSONPath(data, "$.chapters[*].title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := jsonString(values); actual != want.String() {
+		t.Errorf("应按文档中的顺序，实际 %q", actual)
+	}
+
+	// 没有字段顺序时，整数字段名按数值排序
+	object := map[string]interface{}{}
+	for i := 1; i <= 12; i++ {
+		object[strconv.Itoa(i)] = fmt.Sprintf("第%d章", i)
+	}
+	if values, _ = evalJSONPath(object, "$[*]"); jsonString(values) != want.String() {
+		t.Errorf("应按数值排序，实际 %q", jsonString(values))
+	}
+
+	// 取到对象时按原来的字段顺序编码
+	if values, _ = evalJSONPath(data, "$.chapters.2"); jsonString(values) != `{"title":"第2章"}` {
+		t.Errorf("对象编码为 %q", jsonString(values))
+	}
+	if reordered, _ := decodeJSON([]byte(`{"b": 1, "a": [true, null]}`)); jsonString(reordered) != `{"b":1,"a":[true,null]}` {
+		t.Errorf("对象编码为 %q", jsonString(reordered))
+	}
+}
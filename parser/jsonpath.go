@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonObject 按文档中的顺序保存字段的 JSON 对象，以 id 为字段名的章节列表保持原来的顺序
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// MarshalJSON 按原来的字段顺序编码
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// decodeJSON 解码 JSON，对象解码为 jsonObject，数字保留为 json.Number
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeJSONValue(decoder)
+}
+
+// decodeJSONValue 逐个读取 token 解码一个值
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		// 字符串、数字、布尔值或 null
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := jsonObject{values: map[string]interface{}{}}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := token.(string)
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := object.values[key]; !exists {
+				object.keys = append(object.keys, key)
+			}
+			object.values[key] = value
+		}
+		_, err = decoder.Token()
+		return object, err
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return nil, fmt.Errorf("JSON 语法错误: %v", delim)
+}
+
+// sortedKeys 对象的字段名，都是整数时按数值排序，否则按字符串排序
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	numeric := true
+	for k := range object {
+		keys = append(keys, k)
+		if _, err := strconv.Atoi(k); err != nil {
+			numeric = false
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// jsonPathStep JSONPath 中的一步：对象字段、数组下标或通配符
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath 解析 JSONPath 表达式，支持 $.a.b、$['a']、$.a[0]、$.a[-1] 和 $.a[*]
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+
+	var steps []jsonPathStep
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			key := expr[:end]
+			if key == "" {
+				return nil, fmt.Errorf("JSONPath 字段名为空")
+			}
+			if key == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				steps = append(steps, jsonPathStep{key: key})
+			}
+			expr = expr[end:]
+		case '[':
+			end := strings.Index(expr, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath 缺少 ]: %s", expr)
+			}
+			inner := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath 下标无效: %s", inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			// 允许省略开头的 $.
+			if len(steps) == 0 {
+				expr = "." + expr
+				continue
+			}
+			return nil, fmt.Errorf("JSONPath 语法错误: %s", expr)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath 在 decodeJSON 解码的 JSON 上执行 JSONPath，返回所有匹配的值
+func evalJSONPath(data interface{}, expr string) ([]interface{}, error) {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{data}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case jsonObject:
+				if step.wildcard {
+					for _, k := range v.keys {
+						next = append(next, v.values[k])
+					}
+				} else if item, ok := v.values[step.key]; ok && !step.isIndex {
+					next = append(next, item)
+				}
+			case map[string]interface{}:
+				if step.wildcard {
+					// 没有字段顺序时按字段名排序，结果顺序固定
+					for _, k := range sortedKeys(v) {
+						next = append(next, v[k])
+					}
+				} else if item, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, item)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex {
+					index := step.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// jsonString 将 JSON 值转换为字符串，数组按行拼接，null 返回空字符串
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		lines := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := jsonString(item); s != "" {
+				lines = append(lines, s)
+			}
+		}
+		return strings.Join(lines, "\n")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
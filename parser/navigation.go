@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// linkKind 导航链接的类型
+type linkKind int
+
+const (
+	linkNext linkKind = iota // 无法区分页或章的“下一个”
+	linkPrev
+	linkNextPage
+	linkPrevPage
+	linkNextChapter
+	linkPrevChapter
+	linkCatalog
+)
+
+// navLinks 按类型保存检测到的导航链接
+type navLinks map[linkKind]string
+
+// set 只记录每种类型第一次检测到的链接，检测顺序即优先级
+func (l navLinks) set(kind linkKind, href string) {
+	href = strings.TrimSpace(href)
+	if href == "" || href == "#" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return
+	}
+	if _, ok := l[kind]; !ok {
+		l[kind] = href
+	}
+}
+
+// first 按顺序返回第一个存在的链接
+func (l navLinks) first(kinds ...linkKind) string {
+	for _, kind := range kinds {
+		if href, ok := l[kind]; ok {
+			return href
+		}
+	}
+	return ""
+}
+
+var (
+	// navTexts 去掉空白和箭头后的链接文字与类型的对应关系，按前缀匹配，长的在前。
+	// 英文词后面不能紧跟字母，避免“Preview”“index of authors”之类的链接被当作导航
+	navTexts = []struct {
+		text string
+		kind linkKind
+	}{
+		{"下一章", linkNextChapter}, {"下一节", linkNextChapter}, {"下一回", linkNextChapter}, {"下章", linkNextChapter},
+		{"nextchapter", linkNextChapter},
+		{"下一页", linkNextPage}, {"下页", linkNextPage}, {"nextpage", linkNextPage},
+		{"上一章", linkPrevChapter}, {"上一节", linkPrevChapter}, {"上一回", linkPrevChapter}, {"上章", linkPrevChapter},
+		{"previouschapter", linkPrevChapter}, {"prevchapter", linkPrevChapter},
+		{"上一页", linkPrevPage}, {"上页", linkPrevPage}, {"previouspage", linkPrevPage}, {"prevpage", linkPrevPage},
+		{"下一篇", linkNext}, {"next", linkNext},
+		{"上一篇", linkPrev}, {"previous", linkPrev}, {"prev", linkPrev},
+		{"章节目录", linkCatalog}, {"返回目录", linkCatalog}, {"回目录", linkCatalog}, {"章节列表", linkCatalog},
+		{"返回书页", linkCatalog}, {"返回列表", linkCatalog}, {"目录", linkCatalog}, {"书页", linkCatalog},
+		{"tableofcontents", linkCatalog}, {"contents", linkCatalog}, {"toc", linkCatalog}, {"index", linkCatalog},
+	}
+
+	// navScriptPattern 匹配键盘翻页脚本中的变量，例如 var next_page = "/1_1/3.html";
+	navScriptPattern = regexp.MustCompile(`(?i)\b(next_?page|prev_?page|preview_?page|index_?page|book_?url)\s*=\s*["']([^"']+)["']`)
+
+	navNextArrows = "→»›>⟶⇨"
+	navPrevArrows = "←«‹<⟵⇦"
+	navStripChars = navNextArrows + navPrevArrows + "[]【】()（）:：·.。、|"
+)
+
+// parseNavigation 检测页面中的上一页、下一页、上一章、下一章和目录链接
+// 优先级：rel 属性 > 链接文字 > 翻页脚本变量 > id/class
+func (p *GeneralParser) parseNavigation(document *goquery.Document) navLinks {
+	links := navLinks{}
+
+	// <link rel="next"> 和 <a rel="next">
+	document.Find(`link[rel], a[rel]`).Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch rel {
+			case "next":
+				links.set(linkNext, href)
+			case "prev", "previous":
+				links.set(linkPrev, href)
+			case "contents", "index", "toc":
+				links.set(linkCatalog, href)
+			}
+		}
+	})
+
+	// 链接文字
+	anchors := document.Find("body").Find("a[href]")
+	anchors.Each(func(i int, s *goquery.Selection) {
+		if kind, ok := classifyNavText(s.Text()); ok {
+			links.set(kind, s.AttrOr("href", ""))
+		}
+	})
+
+	// 翻页脚本变量
+	document.Find("script").Each(func(i int, s *goquery.Selection) {
+		for _, match := range navScriptPattern.FindAllStringSubmatch(s.Text(), -1) {
+			name := strings.ToLower(strings.ReplaceAll(match[1], "_", ""))
+			switch name {
+			case "nextpage":
+				links.set(linkNext, match[2])
+			case "prevpage", "previewpage":
+				links.set(linkPrev, match[2])
+			case "indexpage", "bookurl":
+				links.set(linkCatalog, match[2])
+			}
+		}
+	})
+
+	// id 和 class
+	anchors.Each(func(i int, s *goquery.Selection) {
+		attrs := strings.ToLower(s.AttrOr("id", "") + " " + s.AttrOr("class", ""))
+		href := s.AttrOr("href", "")
+		switch {
+		case strings.Contains(attrs, "next"):
+			links.set(linkNext, href)
+		case strings.Contains(attrs, "prev"):
+			links.set(linkPrev, href)
+		case strings.Contains(attrs, "mulu") || strings.Contains(attrs, "catalog"):
+			links.set(linkCatalog, href)
+		}
+	})
+
+	return links
+}
+
+// classifyNavText 根据链接文字判断导航类型
+func classifyNavText(text string) (linkKind, bool) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), ""))
+	next := strings.ContainsAny(text, navNextArrows)
+	prev := strings.ContainsAny(text, navPrevArrows)
+	text = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(navStripChars, r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	if text == "" {
+		// 只有箭头的链接
+		switch {
+		case next && !prev:
+			return linkNext, true
+		case prev && !next:
+			return linkPrev, true
+		}
+		return 0, false
+	}
+
+	for _, nav := range navTexts {
+		if !strings.HasPrefix(text, nav.text) {
+			continue
+		}
+		if rest := text[len(nav.text):]; isASCIILetter(nav.text[0]) && rest != "" && isASCIILetter(rest[0]) {
+			continue
+		}
+		return nav.kind, true
+	}
+	return 0, false
+}
+
+// isASCIILetter 判断是否为英文字母
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	opdsAcquisitionRel = "http://opds-spec.org/acquisition"
+	openSearchType     = "application/opensearchdescription+xml"
+
+	// EPUBType EPUB 文件的媒体类型
+	EPUBType = "application/epub+zip"
+)
+
+// OPDSLink OPDS 条目中的链接，地址已转换为绝对地址
+type OPDSLink struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+}
+
+// OPDSEntry 目录中的一项，可能是子目录，也可能是一本书
+type OPDSEntry struct {
+	Title   string
+	Author  string
+	Summary string
+	Links   []OPDSLink
+}
+
+// Navigation 子目录的地址，不是子目录时返回空。
+// 有下载链接的是书，type=entry 的链接指向这一项的详情，都不是子目录
+func (e OPDSEntry) Navigation() string {
+	for _, link := range e.Links {
+		if strings.HasPrefix(link.Rel, opdsAcquisitionRel) {
+			return ""
+		}
+	}
+	for _, link := range e.Links {
+		if strings.HasPrefix(link.Type, "application/atom+xml") && !strings.Contains(link.Type, "type=entry") {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// Acquisition 指定格式的下载地址，没有时返回空
+func (e OPDSEntry) Acquisition(mediaType string) string {
+	for _, link := range e.Links {
+		if strings.HasPrefix(link.Rel, opdsAcquisitionRel) && strings.HasPrefix(link.Type, mediaType) {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// OPDSFeed OPDS 1.x 目录的一页
+type OPDSFeed struct {
+	URL     string
+	Title   string
+	Entries []OPDSEntry
+	Next    string // 下一页的地址
+	Search  string // 搜索地址，OpenSearch 描述或含 {searchTerms} 的模板
+}
+
+// atomFeed 解析 Atom 时用到的部分
+type atomFeed struct {
+	Title   string     `xml:"title"`
+	Links   []OPDSLink `xml:"link"`
+	Entries []struct {
+		Title   string     `xml:"title"`
+		Authors []string   `xml:"author>name"`
+		Summary string     `xml:"summary"`
+		Content string     `xml:"content"`
+		Links   []OPDSLink `xml:"link"`
+	} `xml:"entry"`
+}
+
+// resolveLinks 把相对地址转换为绝对地址
+func resolveLinks(base *url.URL, links []OPDSLink) []OPDSLink {
+	for i, link := range links {
+		links[i].Href = resolveHref(base, link.Href)
+	}
+	return links
+}
+
+// resolveHref 把相对地址转换为绝对地址。含 {searchTerms} 的搜索模板直接拼接，
+// 以免花括号被转义
+func resolveHref(base *url.URL, href string) string {
+	if !strings.Contains(href, "{") {
+		if ref, err := url.Parse(href); err == nil {
+			return base.ResolveReference(ref).String()
+		}
+		return href
+	}
+	switch {
+	case strings.Contains(href, "://"):
+		return href
+	case strings.HasPrefix(href, "/"):
+		return base.Scheme + "://" + base.Host + href
+	}
+	return base.Scheme + "://" + base.Host + path.Join(path.Dir(base.Path), href)
+}
+
+// ParseOPDS 解析 OPDS 目录，相对地址以 feedURL 为基准
+func ParseOPDS(feedURL string, data []byte) (*OPDSFeed, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	var atom atomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("不是有效的 OPDS 目录: %w", err)
+	}
+
+	feed := &OPDSFeed{URL: feedURL, Title: strings.TrimSpace(atom.Title)}
+	for _, link := range resolveLinks(base, atom.Links) {
+		switch {
+		case link.Rel == "next" && feed.Next == "":
+			feed.Next = link.Href
+		case link.Rel == "search" && (feed.Search == "" || link.Type == openSearchType):
+			// Calibre 同时提供 OpenSearch 描述和 Atom 模板，优先使用前者
+			feed.Search = link.Href
+		}
+	}
+	for _, e := range atom.Entries {
+		entry := OPDSEntry{
+			Title:   strings.TrimSpace(e.Title),
+			Author:  strings.Join(e.Authors, ", "),
+			Summary: strings.TrimSpace(e.Summary),
+			Links:   resolveLinks(base, e.Links),
+		}
+		if entry.Summary == "" {
+			entry.Summary = strings.TrimSpace(e.Content)
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
+
+// FetchOPDS 读取 OPDS 目录
+func FetchOPDS(client HttpClient, feedURL string) (*OPDSFeed, error) {
+	data, err := client.FetchUrl(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOPDS(feedURL, data)
+}
+
+// optionalParam OpenSearch 模板中的可选参数
+var optionalParam = regexp.MustCompile(`\{[^}]*\?\}`)
+
+// OPDSSearchURL 按目录的搜索地址拼出搜索 query 的地址
+func OPDSSearchURL(client HttpClient, search string, query string) (string, error) {
+	if search == "" {
+		return "", errors.New("这个书库不支持搜索")
+	}
+	template := search
+	if !strings.Contains(search, "{searchTerms}") {
+		// OpenSearch 描述，从中找出返回 Atom 的模板
+		data, err := client.FetchUrl(search)
+		if err != nil {
+			return "", err
+		}
+		var description struct {
+			URLs []struct {
+				Type     string `xml:"type,attr"`
+				Template string `xml:"template,attr"`
+			} `xml:"Url"`
+		}
+		if err := xml.Unmarshal(data, &description); err != nil {
+			return "", fmt.Errorf("不是有效的 OpenSearch 描述: %w", err)
+		}
+		template = ""
+		for _, u := range description.URLs {
+			if strings.HasPrefix(u.Type, "application/atom+xml") {
+				template = u.Template
+				break
+			}
+		}
+		if template == "" {
+			return "", errors.New("OpenSearch 描述中没有 Atom 搜索地址")
+		}
+		base, err := url.Parse(search)
+		if err != nil {
+			return "", err
+		}
+		template = resolveHref(base, template)
+	}
+
+	// {startPage?} 等可选参数直接去掉；关键字在路径中或查询参数中时转义方式不同
+	template = optionalParam.ReplaceAllString(template, "")
+	escaped := url.PathEscape(query)
+	if q := strings.Index(template, "?"); q >= 0 && q < strings.Index(template, "{searchTerms}") {
+		escaped = url.QueryEscape(query)
+	}
+	return strings.ReplaceAll(template, "{searchTerms}", escaped), nil
+}
+
+// Download 下载文件，客户端支持时不转换编码
+func Download(client HttpClient, fileURL string) ([]byte, error) {
+	if downloader, ok := client.(Downloader); ok {
+		return downloader.Download(fileURL)
+	}
+	return client.FetchUrl(fileURL)
+}
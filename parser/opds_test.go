@@ -0,0 +1,82 @@
+package parser
+
+import "testing"
+
+func TestFetchOPDS(t *testing.T) {
+	client := stubHttpClient{
+		"http://calibre.local:8080/opds/navcatalog/4f": `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>作者: 张三</title>
+  <link rel="next" type="application/atom+xml;type=feed;profile=opds-catalog" href="/opds/navcatalog/4f?offset=25"/>
+  <link rel="search" type="application/atom+xml" href="/opds/search/{searchTerms}"/>
+  <link rel="search" type="application/opensearchdescription+xml" href="/opds/search"/>
+  <entry>
+    <title>某某小说</title>
+    <author><name>张三</name></author>
+    <summary>简介</summary>
+    <link rel="alternate" type="application/atom+xml;type=entry;profile=opds-catalog" href="/opds/book/12"/>
+    <link rel="http://opds-spec.org/acquisition" type="application/epub+zip" href="/get/epub/12/library"/>
+    <link rel="http://opds-spec.org/image" type="image/jpeg" href="/get/cover/12/library"/>
+  </entry>
+  <entry>
+    <title>只有 PDF 的书</title>
+    <link rel="related" type="application/atom+xml;profile=opds-catalog" href="/opds/author/4f"/>
+    <link rel="http://opds-spec.org/acquisition/open-access" type="application/pdf" href="/get/pdf/13"/>
+  </entry>
+  <entry>
+    <title>按系列</title>
+    <content type="text">3 个系列</content>
+    <link type="application/atom+xml;profile=opds-catalog" href="series"/>
+  </entry>
+</feed>`,
+		"http://calibre.local:8080/opds/search": `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <Url type="text/html" template="/browse/search?query={searchTerms}"/>
+  <Url type="application/atom+xml" template="/opds/search/{searchTerms}?page={startPage?}"/>
+</OpenSearchDescription>`,
+	}
+
+	feed, err := FetchOPDS(client, "http://calibre.local:8080/opds/navcatalog/4f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "作者: 张三" || feed.Next != "http://calibre.local:8080/opds/navcatalog/4f?offset=25" {
+		t.Errorf("feed = %+v", feed)
+	}
+	if feed.Search != "http://calibre.local:8080/opds/search" {
+		t.Errorf("Search = %q, 应优先使用 OpenSearch 描述", feed.Search)
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(feed.Entries))
+	}
+
+	book := feed.Entries[0]
+	if book.Author != "张三" || book.Summary != "简介" || book.Navigation() != "" {
+		t.Errorf("book = %+v", book)
+	}
+	if got := book.Acquisition(EPUBType); got != "http://calibre.local:8080/get/epub/12/library" {
+		t.Errorf("Acquisition = %q", got)
+	}
+	if pdf := feed.Entries[1]; pdf.Navigation() != "" || pdf.Acquisition(EPUBType) != "" {
+		t.Errorf("有下载链接的是书，不是子目录: %+v", pdf)
+	}
+	series := feed.Entries[2]
+	if got := series.Navigation(); got != "http://calibre.local:8080/opds/navcatalog/series" {
+		t.Errorf("Navigation = %q", got)
+	}
+	if series.Summary != "3 个系列" || series.Acquisition(EPUBType) != "" {
+		t.Errorf("series = %+v", series)
+	}
+
+	search, err := OPDSSearchURL(client, feed.Search, "某某 小说")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://calibre.local:8080/opds/search/%E6%9F%90%E6%9F%90%20%E5%B0%8F%E8%AF%B4?page="; search != want {
+		t.Errorf("OPDSSearchURL = %q, want %q", search, want)
+	}
+	search, err = OPDSSearchURL(client, "http://calibre.local:8080/opds/books?q={searchTerms}", "a b")
+	if err != nil || search != "http://calibre.local:8080/opds/books?q=a+b" {
+		t.Errorf("OPDSSearchURL = %q, %v", search, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"strings"
 	"unicode/utf8"
 
 	"golang.org/x/text/encoding"
@@ -87,6 +88,8 @@ func (p *PlainTextParser) ParseNovel(url string) (NovelResult, error) {
 	result := NovelResult{
 		Content: p.context,
 		Title:   path.Base(p.url), // 使用文件名作为标题
+		// 去掉扩展名作为书名
+		BookTitle: strings.TrimSuffix(path.Base(p.url), path.Ext(p.url)),
 		Index: IndexResult{
 			Next: "", // 纯文本文件没有上一章/下一章概念
 			Prev: "",
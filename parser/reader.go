@@ -5,13 +5,32 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 )
 
+// prefetchTTL 预读的章节在这段时间后不再使用，重新读取
+const prefetchTTL = 10 * time.Minute
+
+// prefetchedChapter 预读的章节和读取时间
+type prefetchedChapter struct {
+	result NovelResult
+	at     time.Time
+}
+
 type Reader struct {
 	url     string
 	parser  IParser
 	content *NovelResult
 	loading bool
+	end     bool // 已读到最新章节
+	cache   *ChapterCache
+	convert func(string) string // 显示前对正文和标题的转换，例如简繁转换
+
+	prefetch   int // 预读的章节数
+	prefetched map[string]prefetchedChapter
+	prefetchID int // 开始新的预读或跳转时加一，之前的预读不再保存结果
+	mu         sync.Mutex
 }
 
 func NewReaderWithParser(parser IParser) *Reader {
@@ -22,6 +41,17 @@ func NewReaderWithParser(parser IParser) *Reader {
 }
 
 func NewReaderUrl(url string) *Reader {
+	return NewReaderUrlWithClient(url, &DefaultHttpClient{})
+}
+
+// NewReaderUrlWithClient 根据地址创建 reader，网页使用指定的HTTP客户端
+func NewReaderUrlWithClient(url string, client HttpClient) *Reader {
+	if IsEPUB(url) {
+		return &Reader{
+			parser: NewEPUBParser(),
+			url:    url,
+		}
+	}
 	if !strings.HasPrefix(url, "http") {
 		return &Reader{
 			parser: NewPlainTextParser(url),
@@ -29,7 +59,7 @@ func NewReaderUrl(url string) *Reader {
 		}
 	} else {
 		return &Reader{
-			parser: NewGeneralParser(&DefaultHttpClient{}),
+			parser: NewGeneralParser(client),
 			url:    url,
 		}
 	}
@@ -43,41 +73,324 @@ func NewReaderWithoutUrl() *Reader {
 
 func (r *Reader) Read() (*NovelResult, error) {
 	r.loading = true
-	result, err := r.parser.ParseNovel(r.url)
+	result, err := r.parse(r.url)
 	r.loading = false
 	if err == nil {
 		r.content = &result
+		r.end = false
+		// 目录页不缓存，缓存失败不影响阅读
+		if r.cache != nil && !r.isCatalogContent(result.Content) {
+			r.cache.Put(r.url, result)
+		}
+		r.prefetchNext()
+	}
+	return r.converted(result), err
+}
+
+// SetConvert 设置显示前对正文和标题的转换，nil 表示不转换
+func (r *Reader) SetConvert(convert func(string) string) {
+	r.convert = convert
+}
+
+// convertText 转换一段文字
+func (r *Reader) convertText(s string) string {
+	if r.convert == nil {
+		return s
 	}
-	return &result, err
+	return r.convert(s)
 }
 
+// converted 返回转换后的结果，原始结果保持不变
+func (r *Reader) converted(result NovelResult) *NovelResult {
+	result.Content = r.convertText(result.Content)
+	result.Title = r.convertText(result.Title)
+	result.BookTitle = r.convertText(result.BookTitle)
+	result.Author = r.convertText(result.Author)
+	return &result
+}
+
+// Current 返回当前章节转换后的内容，用于修改转换后重新显示
+func (r *Reader) Current() *NovelResult {
+	if r.content == nil {
+		return &NovelResult{}
+	}
+	return r.converted(*r.content)
+}
+
+// Raw 返回当前章节未经转换的内容，例如换源时按原文比较书名和章节名
+func (r *Reader) Raw() NovelResult {
+	if r.content == nil {
+		return NovelResult{}
+	}
+	return *r.content
+}
+
+// parse 解析章节，优先使用预读的结果
+func (r *Reader) parse(url string) (NovelResult, error) {
+	r.mu.Lock()
+	chapter, ok := r.prefetched[url]
+	delete(r.prefetched, url)
+	r.mu.Unlock()
+	if ok && time.Since(chapter.at) < prefetchTTL {
+		return chapter.result, nil
+	}
+	return r.parser.ParseNovel(url)
+}
+
+// leave 将要读取 url，不是预读过的章节时说明跳到了别处，清空预读
+func (r *Reader) leave(url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.prefetched[url]; !ok {
+		r.prefetched = nil
+		r.prefetchID++
+	}
+}
+
+// SetPrefetch 设置预读的章节数，0 表示不预读
+func (r *Reader) SetPrefetch(n int) {
+	r.prefetch = n
+}
+
+// prefetchNext 在后台预读后面的章节，只保留当前章节之后 prefetch 章以内的结果。
+// 没有下一章链接的是最新章节，网站还可能更新，不预读
+func (r *Reader) prefetchNext() {
+	if r.prefetch <= 0 || r.content == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.prefetchID++
+	id := r.prefetchID
+	r.mu.Unlock()
+	base, next := r.url, r.content.Index.Next
+	go func() {
+		window := map[string]bool{}
+		defer func() {
+			r.mu.Lock()
+			if id == r.prefetchID {
+				for url := range r.prefetched {
+					if !window[url] {
+						delete(r.prefetched, url)
+					}
+				}
+			}
+			r.mu.Unlock()
+		}()
+
+		for i := 0; i < r.prefetch && next != ""; i++ {
+			navURL := resolveURL(base, next)
+			r.mu.Lock()
+			chapter, ok := r.prefetched[navURL]
+			r.mu.Unlock()
+			if !ok || time.Since(chapter.at) >= prefetchTTL {
+				result, err := r.parser.ParseNovel(navURL)
+				if err != nil || result.Index.Next == "" {
+					return
+				}
+				chapter = prefetchedChapter{result: result, at: time.Now()}
+				r.mu.Lock()
+				if id != r.prefetchID {
+					// 已经开始了新的预读或跳到了别处
+					r.mu.Unlock()
+					return
+				}
+				if r.prefetched == nil {
+					r.prefetched = map[string]prefetchedChapter{}
+				}
+				r.prefetched[navURL] = chapter
+				r.mu.Unlock()
+			}
+			window[navURL] = true
+			base, next = navURL, chapter.result.Index.Next
+		}
+	}()
+}
+
+// SetCache 设置章节缓存
+func (r *Reader) SetCache(cache *ChapterCache) {
+	r.cache = cache
+}
+
+// ReadNext 读取下一页或下一章。下一章链接指向目录页或没有正文时，
+// 保留当前内容并返回标记为 EndOfBook 的结果
 func (r *Reader) ReadNext() (*NovelResult, error) {
-	if r.content.Index.Next != "" {
-		r.url = r.handlePageNavigation(r.content.Index.Next)
-		return r.Read()
+	if r.content == nil {
+		return r.markEnd(), nil
 	}
-	return &NovelResult{}, nil
+	return r.readNext(r.content.Index.Next)
+}
+
+// ReadNextChapter 跳过本章剩余的分页，读取下一章
+func (r *Reader) ReadNextChapter() (*NovelResult, error) {
+	if r.content == nil {
+		return r.markEnd(), nil
+	}
+	if r.content.Index.NextChapter == "" {
+		return r.readNext(r.content.Index.Next)
+	}
+	return r.readNext(r.content.Index.NextChapter)
+}
+
+// readNext 读取 link 指向的下一页或下一章
+func (r *Reader) readNext(link string) (*NovelResult, error) {
+	if link == "" {
+		return r.markEnd(), nil
+	}
+
+	nextURL := r.handlePageNavigation(link)
+	if r.isCatalogURL(nextURL) {
+		return r.markEnd(), nil
+	}
+
+	prevURL, prevContent := r.url, r.content
+	result, err := r.readAt(nextURL)
+	if err != nil {
+		return result, err
+	}
+	if r.isCatalogContent(result.Content) {
+		r.url, r.content = prevURL, prevContent
+		return r.markEnd(), nil
+	}
+	return result, nil
 }
 
 func (r *Reader) ReadPrev() (*NovelResult, error) {
-	if r.content.Index.Prev != "" {
-		r.url = r.handlePageNavigation(r.content.Index.Prev)
-		return r.Read()
+	if r.content != nil && r.content.Index.Prev != "" {
+		return r.readAt(r.handlePageNavigation(r.content.Index.Prev))
 	}
 	return &NovelResult{}, nil
 }
 
+// ReadPrevChapter 读取上一章，没有上一章链接时读取上一页
+func (r *Reader) ReadPrevChapter() (*NovelResult, error) {
+	if r.content != nil && r.content.Index.PrevChapter != "" {
+		return r.readAt(r.handlePageNavigation(r.content.Index.PrevChapter))
+	}
+	return r.ReadPrev()
+}
+
+// readAt 读取 navURL，失败时保留原来的地址，与仍在显示的内容保持一致
+func (r *Reader) readAt(navURL string) (*NovelResult, error) {
+	prevURL := r.url
+	r.leave(navURL)
+	r.url = navURL
+	result, err := r.Read()
+	if err != nil {
+		r.url = prevURL
+	}
+	return result, err
+}
+
+// NextURL 返回下一页或下一章的地址，已是最新章节时返回空
+func (r *Reader) NextURL() string {
+	if r.content == nil || r.content.Index.Next == "" {
+		return ""
+	}
+	next := r.handlePageNavigation(r.content.Index.Next)
+	if r.isCatalogURL(next) {
+		return ""
+	}
+	return next
+}
+
+// PrevURL 返回上一页或上一章的地址
+func (r *Reader) PrevURL() string {
+	if r.content == nil || r.content.Index.Prev == "" {
+		return ""
+	}
+	return r.handlePageNavigation(r.content.Index.Prev)
+}
+
+// markEnd 标记已读到最新章节，返回当前内容的副本
+func (r *Reader) markEnd() *NovelResult {
+	r.end = true
+	result := r.Current()
+	result.EndOfBook = true
+	return result
+}
+
+// isCatalogURL 判断链接是否指向目录页或书页
+func (r *Reader) isCatalogURL(navURL string) bool {
+	if r.content != nil && r.content.Index.Catalog != "" {
+		catalog := r.handlePageNavigation(r.content.Index.Catalog)
+		if strings.TrimSuffix(catalog, "/") == strings.TrimSuffix(navURL, "/") {
+			return true
+		}
+	}
+
+	current, err := url.Parse(r.url)
+	if err != nil {
+		return false
+	}
+	next, err := url.Parse(navURL)
+	if err != nil || next.Host != current.Host {
+		return false
+	}
+
+	// 指向当前章节所在目录，例如 /1_1/2.html 的下一章为 /1_1/ 或 /1_1/index.html
+	dir := next.Path
+	switch path.Base(dir) {
+	case "index.html", "index.htm", "index.php":
+		dir = path.Dir(dir)
+	default:
+		if !strings.HasSuffix(dir, "/") && dir != "" {
+			return false
+		}
+	}
+	dir = strings.TrimSuffix(dir, "/") + "/"
+	return next.RawQuery == "" && strings.HasPrefix(current.Path, dir)
+}
+
+// isCatalogContent 判断正文是否为目录页。EPUB 读取时已跳过目录页，
+// 正文中的章节列表（例如书中自带的目录）也是这本书的内容
+func (r *Reader) isCatalogContent(content string) bool {
+	if _, ok := r.parser.(*EPUBParser); ok {
+		return false
+	}
+	return isCatalogContent(content)
+}
+
+// isCatalogContent 判断解析出的正文是否为空或者是章节列表
+func isCatalogContent(content string) bool {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+		return true
+	}
+	if len(lines) < 10 {
+		return false
+	}
+
+	matched := 0
+	for _, line := range lines {
+		if chapterTitlePattern.MatchString(line) {
+			matched++
+		}
+	}
+	return matched*2 > len(lines)
+}
+
 func (r *Reader) GetUrl() string {
 	return r.url
 }
 
 func (r *Reader) SetUrl(url string) {
+	r.leave(url)
 	r.url = url
 }
 
 func (r *Reader) handlePageNavigation(navURL string) string {
+	return resolveURL(r.url, navURL)
+}
+
+// resolveURL 将相对链接转换为基于 base 的绝对地址
+func resolveURL(base string, navURL string) string {
+	if !strings.HasPrefix(base, "http") {
+		// 本地文件的链接已是完整路径
+		return navURL
+	}
 	if !strings.HasPrefix(navURL, "http") {
-		currentURL, err := url.Parse(r.url)
+		currentURL, err := url.Parse(base)
 		if err == nil {
 			baseURL := fmt.Sprintf("%s://%s", currentURL.Scheme, currentURL.Host)
 			if strings.HasPrefix(navURL, "/") {
@@ -92,7 +405,7 @@ func (r *Reader) handlePageNavigation(navURL string) string {
 }
 
 func (r *Reader) HasNext() bool {
-	if r.content != nil && r.content.Index.Prev != "" {
+	if r.content != nil && r.content.Index.Next != "" && !r.end {
 		return true
 	}
 	return false
@@ -107,7 +420,7 @@ func (r *Reader) HasPrev() bool {
 
 func (r *Reader) GetTitle() string {
 	if r.content != nil {
-		return r.content.Title
+		return r.convertText(r.content.Title)
 	}
 	return r.url
 }
@@ -115,3 +428,16 @@ func (r *Reader) GetTitle() string {
 func (r *Reader) GetLoading() bool {
 	return r.loading
 }
+
+// IsEnd 是否已读到最新章节
+func (r *Reader) IsEnd() bool {
+	return r.end
+}
+
+// GetBookTitle 返回书名
+func (r *Reader) GetBookTitle() string {
+	if r.content != nil {
+		return r.convertText(r.content.BookTitle)
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubParser 按 URL 返回预设的解析结果
+type stubParser map[string]NovelResult
+
+func (s stubParser) ParseNovel(url string) (NovelResult, error) {
+	return s[url], nil
+}
+
+// failingParser 没有预设结果的地址返回错误
+type failingParser map[string]NovelResult
+
+func (s failingParser) ParseNovel(url string) (NovelResult, error) {
+	result, ok := s[url]
+	if !ok {
+		return NovelResult{}, errors.New("网络错误")
+	}
+	return result, nil
+}
+
+func TestReadFailureKeepsURL(t *testing.T) {
+	r := NewReaderWithParser(failingParser{
+		"https://example.com/book/2.html": {Content: "正文", Index: IndexResult{Next: "3.html", Prev: "1.html", PrevChapter: "1.html"}},
+	})
+	r.SetUrl("https://example.com/book/2.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	reads := map[string]func() (*NovelResult, error){
+		"ReadNext":        r.ReadNext,
+		"ReadPrev":        r.ReadPrev,
+		"ReadPrevChapter": r.ReadPrevChapter,
+	}
+	for name, read := range reads {
+		if _, err := read(); err == nil {
+			t.Fatalf("%s 应返回错误", name)
+		}
+		if r.GetUrl() != "https://example.com/book/2.html" || r.Current().Content != "正文" {
+			t.Errorf("%s 失败后地址应保持不变，实际: %s", name, r.GetUrl())
+		}
+	}
+}
+
+func TestReadNextEndOfBook(t *testing.T) {
+	cases := []struct {
+		name  string
+		pages stubParser
+	}{
+		{
+			name: "下一章指向目录链接",
+			pages: stubParser{
+				"https://example.com/book/2.html": {Content: "正文", Index: IndexResult{Next: "/book/list.html", Catalog: "list.html"}},
+			},
+		},
+		{
+			name: "下一章指向章节所在目录",
+			pages: stubParser{
+				"https://example.com/book/2.html": {Content: "正文", Index: IndexResult{Next: "/book/"}},
+			},
+		},
+		{
+			name: "下一章没有正文",
+			pages: stubParser{
+				"https://example.com/book/2.html": {Content: "正文", Index: IndexResult{Next: "3.html"}},
+				"https://example.com/book/3.html": {Content: ""},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewReaderWithParser(c.pages)
+			r.SetUrl("https://example.com/book/2.html")
+			if _, err := r.Read(); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := r.ReadNext()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.EndOfBook || result.Content != "正文" {
+				t.Errorf("应返回当前章节并标记为最新章节，实际: %+v", result)
+			}
+			if r.GetUrl() != "https://example.com/book/2.html" || r.HasNext() || !r.IsEnd() {
+				t.Errorf("阅读位置应保持不变，实际: %s", r.GetUrl())
+			}
+		})
+	}
+}
+
+func TestReadNext(t *testing.T) {
+	r := NewReaderWithParser(stubParser{
+		"https://example.com/book/2.html": {Content: "正文", Index: IndexResult{Next: "3.html"}},
+		"https://example.com/book/3.html": {Content: "下一章正文"},
+	})
+	r.SetUrl("https://example.com/book/2.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := r.ReadNext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.EndOfBook || result.Content != "下一章正文" || r.GetUrl() != "https://example.com/book/3.html" {
+		t.Errorf("应读取下一章，实际: %+v", result)
+	}
+}
+
+func TestReadNextChapter(t *testing.T) {
+	r := NewReaderWithParser(stubParser{
+		"https://example.com/book/2.html":   {Content: "第一页", Index: IndexResult{Next: "2_2.html", NextChapter: "3.html", PrevChapter: "1.html"}},
+		"https://example.com/book/3.html":   {Content: "下一章正文"},
+		"https://example.com/book/1.html":   {Content: "上一章正文"},
+		"https://example.com/book/2_2.html": {Content: "第二页"},
+	})
+	r.SetUrl("https://example.com/book/2.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.NextURL() != "https://example.com/book/2_2.html" || r.PrevURL() != "" {
+		t.Errorf("上一页和下一页地址有误: %q %q", r.PrevURL(), r.NextURL())
+	}
+
+	result, err := r.ReadNextChapter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Content != "下一章正文" {
+		t.Errorf("应跳过分页读取下一章，实际: %+v", result)
+	}
+
+	r.SetUrl("https://example.com/book/2.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := r.ReadPrevChapter(); result.Content != "上一章正文" {
+		t.Errorf("应读取上一章，实际: %+v", result)
+	}
+}
+
+func TestReaderCache(t *testing.T) {
+	cache := NewChapterCache(t.TempDir())
+	r := NewReaderWithParser(stubParser{
+		"https://example.com/book/2.html": {Content: "正文", Title: "第二章", Index: IndexResult{Next: "3.html"}},
+		"https://example.com/book/3.html": {Content: ""},
+	})
+	r.SetCache(cache)
+	r.SetUrl("https://example.com/book/2.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadNext(); err != nil {
+		t.Fatal(err)
+	}
+
+	chapters, err := cache.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 1 || chapters[0].URL != "https://example.com/book/2.html" || chapters[0].Result.Title != "第二章" {
+		t.Errorf("应只缓存有正文的章节，实际: %+v", chapters)
+	}
+}
+
+// countingParser 记录每个地址被读取的次数
+type countingParser struct {
+	mu     sync.Mutex
+	pages  map[string]NovelResult
+	counts map[string]int
+}
+
+func (p *countingParser) ParseNovel(url string) (NovelResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[url]++
+	return p.pages[url], nil
+}
+
+func (p *countingParser) count(url string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.counts[url]
+}
+
+// waitPrefetched 等待预读的地址变为 want
+func waitPrefetched(t *testing.T, r *Reader, want ...string) {
+	t.Helper()
+	var got []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		got = got[:0]
+		for url := range r.prefetched {
+			got = append(got, url)
+		}
+		r.mu.Unlock()
+		sort.Strings(got)
+		if reflect.DeepEqual(got, want) || len(got) == 0 && len(want) == 0 {
+			return
+		}
+	}
+	t.Fatalf("预读 = %q, want %q", got, want)
+}
+
+func TestPrefetch(t *testing.T) {
+	p := &countingParser{pages: map[string]NovelResult{}, counts: map[string]int{}}
+	for i := 1; i <= 5; i++ {
+		page := NovelResult{Content: fmt.Sprintf("第%d章", i)}
+		if i < 5 {
+			page.Index.Next = fmt.Sprintf("%d.html", i+1)
+		}
+		p.pages[fmt.Sprintf("https://example.com/%d.html", i)] = page
+	}
+	r := NewReaderWithParser(p)
+	r.SetPrefetch(2)
+	r.SetUrl("https://example.com/1.html")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	waitPrefetched(t, r, "https://example.com/2.html", "https://example.com/3.html")
+
+	if result, _ := r.ReadNext(); result.Content != "第2章" || p.count("https://example.com/2.html") != 1 {
+		t.Errorf("应使用预读的章节，实际 %q 读取 %d 次", result.Content, p.count("https://example.com/2.html"))
+	}
+	waitPrefetched(t, r, "https://example.com/3.html", "https://example.com/4.html")
+
+	// 第 5 章是最新章节，不预读
+	r.ReadNext()
+	waitPrefetched(t, r, "https://example.com/4.html")
+
+	// 跳到别处时清空预读
+	r.SetUrl("https://example.com/1.html")
+	waitPrefetched(t, r)
+}
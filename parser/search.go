@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// defaultSearchTimeout 书源没有设置超时时等待搜索结果的时间
+const defaultSearchTimeout = 10 * time.Second
+
+// SearchSource 描述一个书源的搜索接口
+//
+// Results、Title、Author、Link 在 HTML 页面中为 CSS 选择器（Link 取 href），
+// Results 以 $ 开头时按 JSON 解析，各字段为相对每条结果的 JSONPath
+type SearchSource struct {
+	Name     string
+	URL      string // 搜索地址，{keyword} 替换为关键字
+	Encoding string // 关键字的编码，gbk 或为空表示 UTF-8
+	Results  string
+	Title    string
+	Author   string
+	Link     string
+	LinkURL  string // 链接模板，{id} 替换为 Link 取到的值
+	Timeout  time.Duration
+}
+
+// SearchResult 书源中搜到的一本书
+type SearchResult struct {
+	Source string
+	Title  string
+	Author string
+	URL    string // 书的目录页
+}
+
+// BookResult 按书名和作者合并的搜索结果
+type BookResult struct {
+	Title   string
+	Author  string
+	Sources []SearchResult
+}
+
+// searchURL 按书源的编码把关键字填入搜索地址
+func (s SearchSource) searchURL(keyword string) (string, error) {
+	if !strings.Contains(s.URL, "{keyword}") {
+		return "", fmt.Errorf("%s: 搜索地址中没有 {keyword}", s.Name)
+	}
+	switch strings.ToLower(s.Encoding) {
+	case "", "utf-8", "utf8":
+	case "gbk", "gb2312", "gb18030":
+		encoded, err := simplifiedchinese.GBK.NewEncoder().String(keyword)
+		if err != nil {
+			return "", err
+		}
+		keyword = encoded
+	default:
+		return "", fmt.Errorf("%s: 不支持的编码 %s", s.Name, s.Encoding)
+	}
+	return strings.ReplaceAll(s.URL, "{keyword}", url.QueryEscape(keyword)), nil
+}
+
+// Search 在书源中搜索，client 实现 ContextFetcher 时 ctx 结束会取消请求
+func (s SearchSource) Search(ctx context.Context, client HttpClient, keyword string) ([]SearchResult, error) {
+	searchURL, err := s.searchURL(keyword)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if fetcher, ok := client.(ContextFetcher); ok {
+		body, err = fetcher.FetchUrlContext(ctx, searchURL)
+	} else {
+		body, err = client.FetchUrl(searchURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(s.Results), "$") {
+		return s.parseJSON(searchURL, body)
+	}
+	return s.parseHTML(searchURL, body)
+}
+
+// parseHTML 按 CSS 选择器读取搜索结果
+func (s SearchSource) parseHTML(base string, body []byte) ([]SearchResult, error) {
+	document, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	document.Find(s.Results).Each(func(_ int, item *goquery.Selection) {
+		result := SearchResult{
+			Source: s.Name,
+			Title:  normalizeSpace(item.Find(s.Title).First().Text()),
+			Author: normalizeSpace(item.Find(s.Author).First().Text()),
+		}
+		link := item.Find(s.Link).First()
+		if s.Link == "" {
+			link = item
+		}
+		href, _ := link.Attr("href")
+		if result.Title == "" || href == "" {
+			return
+		}
+		result.URL = resolveURL(base, expandURLTemplate(s.LinkURL, href))
+		results = append(results, result)
+	})
+	return results, nil
+}
+
+// parseJSON 按 JSONPath 读取搜索结果
+func (s SearchSource) parseJSON(base string, body []byte) ([]SearchResult, error) {
+	data, err := decodeJSON(body)
+	if err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %w", err)
+	}
+	items, err := evalJSONPath(data, s.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(item interface{}, expr string) string {
+		if expr == "" {
+			return ""
+		}
+		values, err := evalJSONPath(item, expr)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(jsonString(values))
+	}
+	var results []SearchResult
+	for _, item := range items {
+		result := SearchResult{
+			Source: s.Name,
+			Title:  lookup(item, s.Title),
+			Author: lookup(item, s.Author),
+			URL:    expandURLTemplate(s.LinkURL, lookup(item, s.Link)),
+		}
+		if result.Title == "" || result.URL == "" {
+			continue
+		}
+		result.URL = resolveURL(base, result.URL)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// bookKey 合并搜索结果时比较的书名和作者，忽略空白和大小写
+func bookKey(title string, author string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), ""))
+	}
+	return normalize(title) + "\x00" + normalize(author)
+}
+
+// SameBook 书名相同、作者相同或有一方未知时认为是同一本书
+func SameBook(title string, author string, otherTitle string, otherAuthor string) bool {
+	if bookKey(cleanBookTitle(title), "") != bookKey(cleanBookTitle(otherTitle), "") {
+		return false
+	}
+	author, otherAuthor = cleanAuthor(author), cleanAuthor(otherAuthor)
+	return author == "" || otherAuthor == "" || bookKey("", author) == bookKey("", otherAuthor)
+}
+
+// SearchBooks 同时在各书源中搜索，超时的书源记为错误并取消请求。
+// 结果按 SameBook 合并，书名与关键字相同的排在前面，其次是搜到的书源多的
+func SearchBooks(client HttpClient, sources []SearchSource, keyword string) ([]BookResult, []error) {
+	type reply struct {
+		results []SearchResult
+		err     error
+	}
+	replies := make([]reply, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			timeout := source.Timeout
+			if timeout <= 0 {
+				timeout = defaultSearchTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			done := make(chan reply, 1)
+			go func() {
+				results, err := source.Search(ctx, client, keyword)
+				done <- reply{results, err}
+			}()
+			select {
+			case r := <-done:
+				replies[i] = r
+			case <-ctx.Done():
+				replies[i] = reply{err: errors.New("超时")}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var books []BookResult
+	var errs []error
+	for i, r := range replies {
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sources[i].Name, r.err))
+			continue
+		}
+		for _, result := range r.results {
+			result.Author = cleanAuthor(result.Author)
+			n := -1
+			for j, book := range books {
+				if SameBook(book.Title, book.Author, result.Title, result.Author) {
+					n = j
+					break
+				}
+			}
+			if n < 0 {
+				n = len(books)
+				books = append(books, BookResult{Title: result.Title, Author: result.Author})
+			}
+			if books[n].Author == "" {
+				// 先搜到的书源没有作者时补上
+				books[n].Author = result.Author
+			}
+			books[n].Sources = append(books[n].Sources, result)
+		}
+	}
+
+	exact := func(book BookResult) bool {
+		return bookKey(book.Title, "") == bookKey(keyword, "")
+	}
+	sort.SliceStable(books, func(i, j int) bool {
+		if exact(books[i]) != exact(books[j]) {
+			return exact(books[i])
+		}
+		return len(books[i].Sources) > len(books[j].Sources)
+	})
+	return books, errs
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// slowHttpClient 等待 delay 后返回 body
+type slowHttpClient struct {
+	delay time.Duration
+	body  string
+}
+
+func (c slowHttpClient) FetchUrl(url string) ([]byte, error) {
+	time.Sleep(c.delay)
+	return []byte(c.body), nil
+}
+
+// blockingHttpClient 一直等到请求被取消，取消后关闭 canceled
+type blockingHttpClient struct {
+	canceled chan struct{}
+}
+
+func (c blockingHttpClient) FetchUrl(url string) ([]byte, error) {
+	select {}
+}
+
+func (c blockingHttpClient) FetchUrlContext(ctx context.Context, url string) ([]byte, error) {
+	<-ctx.Done()
+	close(c.canceled)
+	return nil, ctx.Err()
+}
+
+// sourceClient 按书源的地址分别使用不同的客户端
+type sourceClient map[string]HttpClient
+
+func (c sourceClient) FetchUrl(url string) ([]byte, error) {
+	for prefix, client := range c {
+		if len(url) >= len(prefix) && url[:len(prefix)] == prefix {
+			return client.FetchUrl(url)
+		}
+	}
+	return nil, nil
+}
+
+func TestSearchBooks(t *testing.T) {
+	client := sourceClient{
+		"https://a.example.com/": stubHttpClient{
+			"https://a.example.com/search?q=%D5%DA%CC%EC": `<html><body>
+				<div class="item"><h3><a href="/book/1/">遮天</a></h3><span class="author">辰东</span></div>
+				<div class="item"><h3><a href="/book/2/">遮天之上</a></h3><span class="author">某人</span></div>
+			</body></html>`,
+		},
+		"https://b.example.com/": stubHttpClient{
+			"https://b.example.com/api/search?kw=%E9%81%AE%E5%A4%A9": `{"data": [
+				{"name": "遮天之上", "author": "某人", "id": 7},
+				{"name": " 遮天", "author": "辰东", "id": 8}
+			]}`,
+		},
+		"https://c.example.com/": slowHttpClient{delay: time.Second},
+	}
+	sources := []SearchSource{
+		{Name: "A", URL: "https://a.example.com/search?q={keyword}", Encoding: "gbk", Results: "div.item", Title: "h3 a", Author: ".author", Link: "h3 a"},
+		{Name: "B", URL: "https://b.example.com/api/search?kw={keyword}", Results: "$.data[*]", Title: "$.name", Author: "$.author", Link: "$.id", LinkURL: "https://b.example.com/book/{id}/"},
+		{Name: "C", URL: "https://c.example.com/search?q={keyword}", Results: "li", Timeout: 10 * time.Millisecond},
+	}
+
+	books, errs := SearchBooks(client, sources, "遮天")
+	if len(errs) != 1 || errs[0].Error() != "C: 超时" {
+		t.Errorf("errs = %v", errs)
+	}
+	expected := []BookResult{
+		{Title: "遮天", Author: "辰东", Sources: []SearchResult{
+			{Source: "A", Title: "遮天", Author: "辰东", URL: "https://a.example.com/book/1/"},
+			{Source: "B", Title: "遮天", Author: "辰东", URL: "https://b.example.com/book/8/"},
+		}},
+		{Title: "遮天之上", Author: "某人", Sources: []SearchResult{
+			{Source: "A", Title: "遮天之上", Author: "某人", URL: "https://a.example.com/book/2/"},
+			{Source: "B", Title: "遮天之上", Author: "某人", URL: "https://b.example.com/book/7/"},
+		}},
+	}
+	if !reflect.DeepEqual(books, expected) {
+		t.Errorf("SearchBooks =\n%+v\nwant\n%+v", books, expected)
+	}
+}
+
+func TestSearchBooksCancel(t *testing.T) {
+	client := blockingHttpClient{canceled: make(chan struct{})}
+	sources := []SearchSource{{Name: "慢", URL: "https://slow.example.com/?q={keyword}", Results: "li", Timeout: 10 * time.Millisecond}}
+
+	if _, errs := SearchBooks(client, sources, "遮天"); len(errs) != 1 {
+		t.Fatalf("errs = %v", errs)
+	}
+	select {
+	case <-client.canceled:
+	case <-time.After(time.Second):
+		t.Error("超时后应取消请求")
+	}
+}
+
+func TestSearchBooksAuthors(t *testing.T) {
+	client := stubHttpClient{
+		"https://a.example.com/search?q=%E9%81%AE%E5%A4%A9": `{"data": [
+			{"name": "遮天", "author": "作者：辰东", "id": 1},
+			{"name": "遮天", "author": "某人", "id": 2}
+		]}`,
+		"https://b.example.com/search?q=%E9%81%AE%E5%A4%A9": `{"data": [{"name": "《遮天》", "author": "", "id": 3}]}`,
+		"https://c.example.com/search?q=%E9%81%AE%E5%A4%A9": `{"data": [{"name": "遮天", "author": "辰东", "id": 4}]}`,
+	}
+	var sources []SearchSource
+	for _, name := range []string{"a", "b", "c"} {
+		sources = append(sources, SearchSource{
+			Name: name, URL: "https://" + name + ".example.com/search?q={keyword}",
+			Results: "$.data[*]", Title: "$.name", Author: "$.author", Link: "$.id", LinkURL: "/book/{id}",
+		})
+	}
+
+	books, errs := SearchBooks(client, sources, "遮天")
+	if len(errs) != 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+	if len(books) != 2 {
+		t.Fatalf("没有作者的结果应与同名的书合并，“作者：”应去掉，实际 %+v", books)
+	}
+	if books[0].Author != "辰东" || len(books[0].Sources) != 3 || books[0].Sources[0].Author != "辰东" {
+		t.Errorf("books[0] = %+v", books[0])
+	}
+	if books[1].Author != "某人" || len(books[1].Sources) != 1 {
+		t.Errorf("books[1] = %+v", books[1])
+	}
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// 段落缩进
+const paragraphIndent = "    "
+
+// blockTags 会打断段落的块级元素
+var blockTags = map[string]bool{
+	"p": true, "div": true, "blockquote": true, "section": true, "article": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"pre": true, "center": true, "table": true, "tr": true, "td": true, "hr": true,
+}
+
+// skipTags 不参与正文渲染的元素
+var skipTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "form": true,
+	"button": true, "select": true, "input": true, "textarea": true, "head": true,
+}
+
+// textRenderer 将 HTML 节点树渲染为按段落划分的纯文本
+type textRenderer struct {
+	paragraphs []string
+	current    strings.Builder
+}
+
+// flush 结束当前段落
+func (r *textRenderer) flush() {
+	content := normalizeSpace(r.current.String())
+	if content != "" {
+		r.paragraphs = append(r.paragraphs, content)
+	}
+	r.current.Reset()
+}
+
+// render 递归渲染节点，depth 为相对于容器的深度
+func (r *textRenderer) render(n *html.Node, depth int) {
+	switch n.Type {
+	case html.TextNode:
+		r.current.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+	if skipTags[tag] {
+		return
+	}
+	// 容器直接包含的链接通常是导航，沿用旧逻辑忽略
+	if tag == "a" && depth == 0 {
+		return
+	}
+	if tag == "br" {
+		r.flush()
+		return
+	}
+
+	block := blockTags[tag]
+	if block {
+		r.flush()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c, depth+1)
+	}
+	if block {
+		r.flush()
+	}
+}
+
+// normalizeSpace 合并空白字符，去掉 &nbsp; 和全角空格。
+// 中文之间的换行、制表符和全角空格多是网站排版时断开的，直接相连
+func normalizeSpace(s string) string {
+	var b strings.Builder
+	var last rune
+	gap, broken := false, false
+	for _, r := range s {
+		switch {
+		case r == '　' || r == '\t' || r == '\n' || r == '\r':
+			gap, broken = true, true
+			continue
+		case unicode.IsSpace(r):
+			gap = true
+			continue
+		}
+		if gap && last != 0 && (!broken || !isCJK(last) && !isCJK(r)) {
+			b.WriteByte(' ')
+		}
+		gap, broken = false, false
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// isCJK 判断是否为中日韩文字或全角标点
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303f || r >= 0xff00 && r <= 0xffef
+}
+
+// renderParagraphs 渲染选区的所有子节点，返回段落列表
+func renderParagraphs(selection *goquery.Selection) []string {
+	r := &textRenderer{}
+	for _, node := range selection.Nodes {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			r.render(c, 0)
+		}
+	}
+	r.flush()
+	return r.paragraphs
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// chapterTitlePattern 匹配“第十章 出山”“Chapter 10”之类的章节标题
+	chapterTitlePattern = regexp.MustCompile(`(?i)(第\s*[0-9零〇一二两三四五六七八九十百千万]+\s*[章节回卷集部篇])|(chapter\s*\d+)`)
+	// pageTitleSeparator 网页标题中书名、站点名之间的分隔符
+	pageTitleSeparator = regexp.MustCompile(`\s*[_|｜—–]\s*|\s+-\s+`)
+	// authorPattern 匹配“作者：某某”
+	authorPattern = regexp.MustCompile(`作\s*者\s*[:：]\s*([^\s　|/,，]+)`)
+	// authorPrefixPattern 作者名前的“作者：”
+	authorPrefixPattern = regexp.MustCompile(`^作\s*者\s*[:：]?\s*`)
+	// bookTitleSuffixes 书名后常见的站点修饰词
+	bookTitleSuffixes = []string{"最新章节列表", "最新章节", "全文阅读", "在线阅读", "免费阅读", "无弹窗", "全文"}
+)
+
+// splitPageTitle 按分隔符拆分网页标题，例如“第十章 出山_某某小说_笔趣阁手机版”
+func splitPageTitle(title string) []string {
+	var parts []string
+	for _, part := range pageTitleSeparator.Split(strings.TrimSpace(title), -1) {
+		part = normalizeSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// cleanBookTitle 去掉书名中的站点修饰词和书名号
+func cleanBookTitle(title string) string {
+	title = normalizeSpace(title)
+	for _, suffix := range bookTitleSuffixes {
+		title = strings.TrimSuffix(title, suffix)
+	}
+	title = strings.TrimPrefix(title, "《")
+	title = strings.TrimSuffix(title, "》")
+	return strings.TrimSpace(title)
+}
+
+// cleanAuthor 去掉作者名前的“作者：”
+func cleanAuthor(author string) string {
+	return authorPrefixPattern.ReplaceAllString(normalizeSpace(author), "")
+}
+
+// metaContent 按顺序读取 meta 标签，返回第一个非空值
+func metaContent(document *goquery.Document, names ...string) string {
+	for _, name := range names {
+		selector := `meta[property="` + name + `"], meta[name="` + name + `"]`
+		if content, ok := document.Find(selector).First().Attr("content"); ok {
+			if content = normalizeSpace(content); content != "" {
+				return content
+			}
+		}
+	}
+	return ""
+}
+
+// findAuthor 在较短的文本元素中查找“作者：某某”
+func findAuthor(document *goquery.Document) string {
+	author := ""
+	document.Find("body *").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		text := s.Text()
+		if utf8.RuneCountInString(text) > 50 {
+			return true
+		}
+		if match := authorPattern.FindStringSubmatch(text); match != nil {
+			author = match[1]
+			return false
+		}
+		return true
+	})
+	return author
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// remoteTimeout 等待阅读界面处理远程请求的时间
+const remoteTimeout = 5 * time.Second
+
+// remoteStatus 远程控制返回的阅读状态
+type remoteStatus struct {
+	State        string  `json:"state"`
+	Book         string  `json:"book"`
+	Chapter      string  `json:"chapter"`
+	URL          string  `json:"url"`
+	ChapterIndex int     `json:"chapterIndex"`
+	ChapterTotal int     `json:"chapterTotal"`
+	Line         int     `json:"line"` // 从 1 开始
+	Lines        int     `json:"lines"`
+	Progress     float64 `json:"progress"`
+	Loading      bool    `json:"loading"`
+	AutoScroll   bool    `json:"autoScroll"`
+	Speaking     bool    `json:"speaking"`
+	Message      string  `json:"message,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// remoteMsg 远程控制请求，阅读界面处理后把状态写入 reply
+type remoteMsg struct {
+	action string
+	params url.Values
+	reply  chan remoteStatus
+}
+
+// status 当前的阅读状态
+func (m model) status() remoteStatus {
+	status := remoteStatus{
+		State:        m.state,
+		ChapterIndex: m.chapterIndex,
+		ChapterTotal: m.chapterTotal,
+		Line:         m.cursor + 1,
+		Lines:        len(m.content),
+		Progress:     m.progress(),
+		AutoScroll:   m.auto,
+		Speaking:     m.speaking,
+		Message:      m.message,
+	}
+	if reader != nil {
+		status.Book = reader.GetBookTitle()
+		status.Chapter = reader.GetTitle()
+		status.URL = reader.GetUrl()
+		status.Loading = reader.GetLoading()
+	}
+	return status
+}
+
+// remote 处理远程控制请求，返回操作后的状态
+func (m model) remote(msg remoteMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var err error
+	if msg.action != "status" {
+		cmd, err = m.remoteAction(msg.action, msg.params)
+	}
+	status := m.status()
+	if err != nil {
+		status.Error = err.Error()
+	}
+	msg.reply <- status
+	return m, cmd
+}
+
+// remoteAction 执行远程操作，与按键一样会停止自动翻页和朗读
+func (m *model) remoteAction(action string, params url.Values) (tea.Cmd, error) {
+	if m.state != "reading" || m.boss || len(m.content) == 0 {
+		return nil, errors.New("当前不在阅读界面")
+	}
+	m.message = ""
+	m.track()
+	m.auto = false
+	m.stopSpeaking()
+
+	switch action {
+	case "next":
+		return m.lineDown(), nil
+	case "prev":
+		return m.lineUp(), nil
+	case "nextChapter":
+		if !reader.HasNext() {
+			return nil, errors.New("已是最新章节")
+		}
+		m.cursor = 0
+		return m.fetch("nextChapter"), nil
+	case "prevChapter":
+		if !reader.HasPrev() {
+			return nil, errors.New("已是第一章")
+		}
+		m.cursor = 0
+		return m.fetch("prevChapter"), nil
+	case "jump":
+		return m.remoteJump(params)
+	case "search":
+		query := params.Get("q")
+		if query == "" {
+			return nil, errors.New("缺少参数 q")
+		}
+		m.runSearch(query, false)
+		if len(m.matches) == 0 {
+			return nil, errors.New(m.message)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("未知的操作 %s", action)
+}
+
+// remoteJump 跳到本章的第 line 行、本章的 percent 处，或目录中的第 chapter 章（从 1 开始）
+func (m *model) remoteJump(params url.Values) (tea.Cmd, error) {
+	last := max(len(m.content)-m.lines, 0)
+	switch {
+	case params.Has("line"):
+		line, err := strconv.Atoi(params.Get("line"))
+		if err != nil {
+			return nil, fmt.Errorf("line 应为整数: %w", err)
+		}
+		m.cursor = min(max(line-1, 0), last)
+	case params.Has("percent"):
+		percent, err := strconv.ParseFloat(params.Get("percent"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("percent 应为数字: %w", err)
+		}
+		m.cursor = min(max(int(percent/100*float64(len(m.content))), 0), last)
+	case params.Has("chapter"):
+		chapter, err := strconv.Atoi(params.Get("chapter"))
+		if err != nil {
+			return nil, fmt.Errorf("chapter 应为整数: %w", err)
+		}
+		if chapter < 1 || chapter > len(m.catalog) {
+			return nil, fmt.Errorf("目录中没有第 %d 章", chapter)
+		}
+		updated, cmd := m.jumpTo(m.catalog[chapter-1].URL, utils.Position{})
+		*m = updated.(model)
+		return cmd, nil
+	default:
+		return nil, errors.New("缺少参数 line、percent 或 chapter")
+	}
+	return nil, nil
+}
+
+// remoteHandler 远程控制的 HTTP 接口，请求通过 send 交给阅读界面处理。
+// token 不为空时请求需带上 Authorization: Bearer <token>
+func remoteHandler(send func(tea.Msg), token string) http.Handler {
+	handle := func(w http.ResponseWriter, r *http.Request, action string) {
+		if err := checkRemote(r, token); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		params, err := remoteParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reply := make(chan remoteStatus, 1)
+		go send(remoteMsg{action: action, params: params, reply: reply})
+
+		select {
+		case status := <-reply:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if status.Error != "" {
+				w.WriteHeader(http.StatusConflict)
+			}
+			json.NewEncoder(w).Encode(status)
+		case <-time.After(remoteTimeout):
+			http.Error(w, "阅读界面没有响应", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, "status")
+	})
+	mux.HandleFunc("POST /{action}", func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, r.PathValue("action"))
+	})
+	return mux
+}
+
+// checkRemote 拒绝网页发来的请求：Host 为域名时可能是 DNS 重绑定，
+// 网页的表单无法以 application/json 提交，不会绕过浏览器的跨域检查
+func checkRemote(r *http.Request, token string) error {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host != "localhost" && net.ParseIP(strings.Trim(host, "[]")) == nil {
+		return fmt.Errorf("不允许的 Host: %s", r.Host)
+	}
+	if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+		return errors.New("token 不正确")
+	}
+	if r.Method == http.MethodPost {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			return errors.New("请求的 Content-Type 应为 application/json")
+		}
+	}
+	return nil
+}
+
+// remoteParams 合并查询字符串和 JSON 请求体中的参数
+func remoteParams(r *http.Request) (url.Values, error) {
+	params := r.URL.Query()
+	if r.Method != http.MethodPost {
+		return params, nil
+	}
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<16))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("请求体应为 JSON 对象: %w", err)
+	}
+	for key, value := range body {
+		params.Set(key, fmt.Sprint(value))
+	}
+	return params, nil
+}
+
+// startRemote 启动远程控制接口，没有指定主机时只监听本机
+func startRemote(addr string, token string, send func(tea.Msg)) (*http.Server, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: remoteHandler(send, token)}
+	go server.Serve(listener)
+	return server, nil
+}
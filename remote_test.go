@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sendRemote 把远程请求交给 Update 处理，返回更新后的模型和状态
+func sendRemote(m model, action string, params url.Values) (model, remoteStatus) {
+	reply := make(chan remoteStatus, 1)
+	updated, _ := m.Update(remoteMsg{action: action, params: params, reply: reply})
+	return updated.(model), <-reply
+}
+
+func TestRemoteActions(t *testing.T) {
+	reader = parser.NewReaderWithoutUrl()
+	m := model{state: "reading", lines: 2}
+	m.setContent(strings.Repeat("一段文字。\n", 9) + "要找的内容")
+
+	m, status := sendRemote(m, "next", nil)
+	if status.Line != 3 || status.Lines != 10 || status.Error != "" {
+		t.Errorf("应向下翻一页，实际 %+v", status)
+	}
+
+	m, status = sendRemote(m, "jump", url.Values{"percent": {"50"}})
+	if status.Line != 6 {
+		t.Errorf("应跳到本章一半处，实际 %+v", status)
+	}
+	m, status = sendRemote(m, "jump", url.Values{"line": {"100"}})
+	if status.Line != 9 {
+		t.Errorf("行号超出时应停在末尾，实际 %+v", status)
+	}
+	m, status = sendRemote(m, "jump", url.Values{"chapter": {"3"}})
+	if status.Error == "" {
+		t.Error("目录中没有的章节应返回错误")
+	}
+
+	m.cursor = 0
+	m, status = sendRemote(m, "search", url.Values{"q": {"要找"}})
+	if status.Line != 10 || status.Error != "" {
+		t.Errorf("应跳到搜索结果，实际 %+v", status)
+	}
+
+	m, status = sendRemote(m, "nextChapter", nil)
+	if status.Error == "" {
+		t.Error("没有下一章时应返回错误")
+	}
+	if _, status = sendRemote(m, "unknown", nil); status.Error == "" {
+		t.Error("未知的操作应返回错误")
+	}
+
+	m.state = "library"
+	if _, status = sendRemote(m, "next", nil); status.Error == "" || status.State != "library" {
+		t.Errorf("不在阅读界面时应返回错误，实际 %+v", status)
+	}
+}
+
+func TestRemoteHandler(t *testing.T) {
+	var actions []string
+	handler := remoteHandler(func(msg tea.Msg) {
+		r := msg.(remoteMsg)
+		actions = append(actions, r.action+" "+r.params.Get("line"))
+		r.reply <- remoteStatus{State: "reading", Line: 5}
+	}, "")
+
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:7878/jump", strings.NewReader(`{"line": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var status remoteStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || rec.Code != http.StatusOK || status.Line != 5 {
+		t.Errorf("响应有误: %d %+v %v", rec.Code, status, err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:7878/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("查询状态应成功，实际 %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:7878/next", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("操作应使用 POST，实际 %d", rec.Code)
+	}
+
+	if len(actions) != 2 || actions[0] != "jump 5" || actions[1] != "status " {
+		t.Errorf("请求应转发给阅读界面，实际 %q", actions)
+	}
+}
+
+func TestRemoteHandlerRejects(t *testing.T) {
+	send := func(msg tea.Msg) {
+		t.Errorf("不应转发请求: %+v", msg)
+	}
+	post := func(target string, contentType string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader("{}"))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		req   *http.Request
+	}{
+		{"表单", "", post("http://127.0.0.1:7878/next", "application/x-www-form-urlencoded")},
+		{"没有 Content-Type", "", post("http://127.0.0.1:7878/next", "")},
+		{"其他域名", "", post("http://evil.example.com:7878/next", "application/json")},
+		{"没有 token", "secret", post("http://127.0.0.1:7878/next", "application/json")},
+		{"查询状态没有 token", "secret", httptest.NewRequest(http.MethodGet, "http://127.0.0.1:7878/status", nil)},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		remoteHandler(send, tt.token).ServeHTTP(rec, tt.req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: 应拒绝请求，实际 %d", tt.name, rec.Code)
+		}
+	}
+
+	var actions []string
+	handler := remoteHandler(func(msg tea.Msg) {
+		r := msg.(remoteMsg)
+		actions = append(actions, r.action)
+		r.reply <- remoteStatus{State: "reading"}
+	}, "secret")
+	req := post("http://[::1]:7878/next", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(actions) != 1 {
+		t.Errorf("带 token 的请求应成功，实际 %d %q", rec.Code, actions)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var searchStyle = lipgloss.NewStyle().Background(lipgloss.Color("3")).Foreground(lipgloss.Color("0"))
+
+// searchHit 全书搜索的一条结果
+type searchHit struct {
+	url      string
+	chapter  string
+	context  string
+	position utils.Position
+}
+
+// searchBookMsg 全书搜索的结果
+type searchBookMsg struct {
+	query string
+	hits  []searchHit
+	err   error
+}
+
+// startSearch 输入本章搜索的关键字，输入时即跳到匹配处
+func (m *model) startSearch(backward bool) tea.Cmd {
+	m.searchOrigin = m.cursor
+	m.searchPrev = m.search
+	m.searchBackward = backward
+	if backward {
+		return m.startInput("searchBackward", "?", "向上搜索本章")
+	}
+	return m.startInput("search", "/", "搜索本章")
+}
+
+// incrementalSearch 是否正在输入本章搜索的关键字
+func (m model) incrementalSearch() bool {
+	return m.state == "input" && (m.inputAction == "search" || m.inputAction == "searchBackward")
+}
+
+// previewSearch 输入关键字时从开始输入的位置查找第一个匹配
+func (m *model) previewSearch(query string) {
+	m.cursor = m.searchOrigin
+	m.search = query
+	m.findMatches()
+	m.message = ""
+	if query != "" {
+		m.jumpMatch(m.searchBackward)
+	}
+}
+
+// cancelSearch 取消输入，回到开始输入的位置并恢复之前的关键字
+func (m *model) cancelSearch() {
+	m.cursor = m.searchOrigin
+	m.search = m.searchPrev
+	m.findMatches()
+	m.message = ""
+}
+
+// runSearch 在当前章节中搜索并跳到第一个匹配
+func (m *model) runSearch(query string, backward bool) {
+	m.search = query
+	m.searchBackward = backward
+	m.findMatches()
+	m.jumpMatch(backward)
+}
+
+// findMatches 重新计算当前章节中的匹配
+func (m *model) findMatches() {
+	m.matches = nil
+	if m.search != "" {
+		m.matches = utils.FindMatches(utils.SplitParagraphs(m.raw), m.search)
+	}
+}
+
+// matchLines 包含匹配的行，按行号排序
+func (m model) matchLines() []int {
+	var lines []int
+	for _, match := range m.matches {
+		line := utils.FindLine(m.content, match.Paragraph, match.Offset)
+		if len(lines) == 0 || lines[len(lines)-1] != line {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// jumpMatch 跳到下一个（backward 时为上一个）匹配，到头后从另一端继续
+func (m *model) jumpMatch(backward bool) {
+	lines := m.matchLines()
+	if len(lines) == 0 {
+		m.message = "未找到: " + m.search
+		return
+	}
+
+	target := -1
+	if backward {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i] < m.cursor {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			target = len(lines) - 1
+		}
+	} else {
+		for i, line := range lines {
+			if line > m.cursor {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			target = 0
+		}
+	}
+
+	m.cursor = lines[target]
+	m.message = fmt.Sprintf("%s %d/%d", m.search, target+1, len(lines))
+}
+
+// renderMatches 渲染一行，标出其中匹配的文字
+func (m model) renderMatches(i int, paragraphs []string) string {
+	line := m.content[i]
+	runes := []rune(line.Text)
+	marked := make([]bool, len(runes))
+	found := false
+	for _, match := range m.matches {
+		if match.Paragraph != line.Paragraph {
+			continue
+		}
+		for k := match.Offset; k < match.Offset+match.Length; k++ {
+			if idx := k - line.Offset; idx >= 0 && idx < len(runes) {
+				marked[idx] = true
+				found = true
+			}
+		}
+	}
+	if !found {
+		return m.renderText(i, paragraphs)
+	}
+
+	var b strings.Builder
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && marked[end] == marked[start] {
+			end++
+		}
+		if marked[start] {
+			b.WriteString(searchStyle.Render(string(runes[start:end])))
+		} else {
+			b.WriteString(textStyle.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return b.String()
+}
+
+// searchBook 在后台搜索这本书缓存的所有章节
+func (m *model) searchBook(query string) tea.Cmd {
+	m.results = nil
+	m.resultSelected = 0
+	m.search = query
+	m.findMatches()
+	m.loading = true
+	m.message = ""
+	m.state = "searchResults"
+
+	origin := m.originUrl
+	return func() tea.Msg {
+		chapters, err := chapterCache(origin).All()
+		if err != nil {
+			return searchBookMsg{query: query, err: err}
+		}
+		var hits []searchHit
+		for _, chapter := range chapters {
+			paragraphs := utils.SplitParagraphs(chapter.Result.Content)
+			for _, match := range utils.FindMatches(paragraphs, query) {
+				hits = append(hits, searchHit{
+					url:      chapter.URL,
+					chapter:  chapter.Result.Title,
+					context:  utils.MatchContext(paragraphs[match.Paragraph], match, 15),
+					position: utils.NewPosition(paragraphs, match.Paragraph, match.Offset),
+				})
+			}
+		}
+		return searchBookMsg{query: query, hits: hits}
+	}
+}
+
+// jumpTo 跳到指定章节的位置，不是当前章节时重新加载
+func (m model) jumpTo(url string, pos utils.Position) (tea.Model, tea.Cmd) {
+	m.state = "reading"
+	if url == reader.GetUrl() {
+		m.cursor = utils.Locate(utils.SplitParagraphs(m.raw), m.content, pos)
+		return m, nil
+	}
+	reader.SetUrl(url)
+	m.restore = &pos
+	return m, func() tea.Msg {
+		return m.fetchNovelContent("")
+	}
+}
+
+// updateResults 全书搜索结果的按键处理
+func (m model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(searchBookMsg); ok {
+		if msg.query != m.search || !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		m.results = msg.hits
+		if msg.err != nil {
+			m.message = fmt.Sprintf("读取缓存失败: %v", msg.err)
+		}
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case keyMsg.String() == "ctrl+c":
+		m.done = true
+		m.flushStats()
+		historyManager.Save(m.historyEntry())
+		return m, tea.Quit
+	case keyMsg.String() == "q" || keyMsg.String() == "esc":
+		m.loading = false
+		m.message = ""
+		m.state = "reading"
+	case keyMsg.String() == "enter":
+		if len(m.results) == 0 {
+			break
+		}
+		hit := m.results[m.resultSelected]
+		return m.jumpTo(hit.url, hit.position)
+	case key.Matches(keyMsg, keys.Down):
+		if m.resultSelected < len(m.results)-1 {
+			m.resultSelected++
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.resultSelected > 0 {
+			m.resultSelected--
+		}
+	}
+	return m, nil
+}
+
+// viewResults 全书搜索结果界面
+func (m model) viewResults() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("搜索“%s”：%d 条结果（仅包含已缓存的章节）\n\n", m.search, len(m.results)))
+
+	rows := len(m.results)
+	if m.height > 4 && rows > m.height-4 {
+		rows = m.height - 4
+	}
+	start := 0
+	if m.resultSelected >= rows {
+		start = m.resultSelected - rows + 1
+	}
+
+	for i := start; i < start+rows && i < len(m.results); i++ {
+		hit := m.results[i]
+		cursor := "  "
+		if i == m.resultSelected {
+			cursor = "> "
+		}
+		b.WriteString(fmt.Sprintf("%s%s\t%s\n", cursor, hit.chapter, hit.context))
+	}
+	if m.loading {
+		b.WriteString("搜索中...\n")
+	}
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 跳转\tq 返回"))
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIncrementalSearch(t *testing.T) {
+	reader = parser.NewReaderWithoutUrl()
+	m := model{state: "reading", lines: 3}
+	m.setContent(strings.Repeat("第一段。\n", 5) + "山门\n" + strings.Repeat("第二段。\n", 5) + "山谷\n")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = updated.(model)
+	for _, r := range "山" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(model)
+	}
+	if m.state != "input" || m.cursor != 5 {
+		t.Fatalf("输入时应跳到第一个匹配，实际 state=%s cursor=%d", m.state, m.cursor)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'谷'}})
+	m = updated.(model)
+	if m.cursor != 11 {
+		t.Errorf("关键字变化时应重新查找，实际 cursor=%d", m.cursor)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.state != "reading" || m.cursor != 0 || m.search != "" || len(m.matches) != 0 {
+		t.Errorf("取消后应回到原来的位置，实际 cursor=%d search=%q", m.cursor, m.search)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'山'}})
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "reading" || m.cursor != 5 || m.search != "山" {
+		t.Errorf("确认后应停在第一个匹配，实际 cursor=%d search=%q", m.cursor, m.search)
+	}
+}
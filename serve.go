@@ -0,0 +1,349 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+//go:embed web/*.html
+var webFiles embed.FS
+
+var webPages = template.Must(template.ParseFS(webFiles, "web/*.html"))
+
+// webServer 网页阅读服务，与终端共用书架和阅读记录
+type webServer struct {
+	mu       sync.Mutex // reader 不能同时读取多个章节，请求依次处理
+	readers  map[string]*parser.Reader
+	catalogs map[string][]parser.Chapter
+	open     func(entry utils.HistoryEntry) (*parser.Reader, error)
+}
+
+// newWebServer 创建网页阅读服务
+func newWebServer() *webServer {
+	return &webServer{
+		readers:  map[string]*parser.Reader{},
+		catalogs: map[string][]parser.Chapter{},
+		open:     openBook,
+	}
+}
+
+// openBook 按阅读记录创建 reader
+func openBook(entry utils.HistoryEntry) (*parser.Reader, error) {
+	r, err := newReader(entry.OriginURL, entry.LastURL, entry.Source)
+	if err != nil {
+		return nil, err
+	}
+	c, err := converter(entry.Convert)
+	if err != nil {
+		return nil, err
+	}
+	r.SetConvert(c.Convert)
+	return r, nil
+}
+
+// reader 返回一本书的 reader，调用时需持有 mu
+func (s *webServer) reader(entry utils.HistoryEntry) (*parser.Reader, error) {
+	if r, ok := s.readers[entry.OriginURL]; ok {
+		return r, nil
+	}
+	r, err := s.open(entry)
+	if err != nil {
+		return nil, err
+	}
+	s.readers[entry.OriginURL] = r
+	return r, nil
+}
+
+// catalog 返回一本书的目录，只解析一次，调用时需持有 mu
+func (s *webServer) catalog(originURL string, r *parser.Reader) []parser.Chapter {
+	chapters, ok := s.catalogs[originURL]
+	if !ok {
+		chapters, _ = r.Catalog()
+		s.catalogs[originURL] = chapters
+	}
+	return chapters
+}
+
+// handler 网页阅读的路由
+func (s *webServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.library)
+	mux.HandleFunc("GET /read", s.read)
+	mux.HandleFunc("GET /toc", s.toc)
+	mux.HandleFunc("POST /progress", s.progress)
+	mux.HandleFunc("GET /opds", s.opdsRoot)
+	mux.HandleFunc("GET /opds/books", s.opdsBooks)
+	mux.HandleFunc("GET /opds/search.xml", s.openSearch)
+	mux.HandleFunc("GET /opds/download/{format}", s.download)
+	return mux
+}
+
+// render 渲染页面
+func render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := webPages.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("%s: %v", name, err)
+	}
+}
+
+// book 按请求中的 book 参数查找阅读记录
+func book(w http.ResponseWriter, r *http.Request) (utils.HistoryEntry, bool) {
+	entry, ok := historyManager.Get(r.FormValue("book"))
+	if !ok {
+		http.Error(w, "书架中没有这本书", http.StatusNotFound)
+	}
+	return entry, ok
+}
+
+// bookName 书名，没有书名时显示地址
+func bookName(entry utils.HistoryEntry) string {
+	if entry.Title != "" {
+		return entry.Title
+	}
+	return entry.OriginURL
+}
+
+// library 书架页面
+func (s *webServer) library(w http.ResponseWriter, r *http.Request) {
+	library, err := historyManager.Load()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type item struct {
+		OriginURL    string
+		Name         string
+		ChapterTitle string
+		Progress     string
+		LastRead     string
+	}
+	items := make([]item, 0, len(library.Books))
+	for _, b := range library.Books {
+		progress := fmt.Sprintf("本章 %.0f%%", b.Progress)
+		if p := b.BookProgress(); p >= 0 {
+			progress = fmt.Sprintf("第%d/%d章 %.1f%%", b.ChapterIndex+1, b.ChapterTotal, p)
+		}
+		items = append(items, item{
+			OriginURL:    b.OriginURL,
+			Name:         bookName(b),
+			ChapterTitle: b.ChapterTitle,
+			Progress:     progress,
+			LastRead:     b.LastRead.Format("2006-01-02 15:04"),
+		})
+	}
+	render(w, "library.html", items)
+}
+
+// read 章节页面，打开的章节记为当前阅读位置
+func (s *webServer) read(w http.ResponseWriter, r *http.Request) {
+	entry, ok := book(w, r)
+	if !ok {
+		return
+	}
+	url := r.FormValue("url")
+	if url == "" {
+		url = entry.LastURL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reader, err := s.reader(entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	allowed, err := s.allowedURL(entry, reader, url)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if !allowed {
+		http.Error(w, "不是这本书的章节", http.StatusBadRequest)
+		return
+	}
+	prevURL := reader.GetUrl()
+	reader.SetUrl(url)
+	result, err := reader.Read()
+	if err != nil {
+		reader.SetUrl(prevURL)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	content := rules.Apply(result.Content)
+	paragraphs := utils.SplitParagraphs(content)
+
+	start := 0
+	if url == entry.LastURL {
+		start = min(entry.Position.Paragraph, max(len(paragraphs)-1, 0))
+	} else {
+		// 换了章节，从头开始
+		entry.Cursor, entry.Position, entry.Progress = 0, utils.Position{}, 0
+	}
+	entry.LastURL = url
+	entry.ChapterTitle = result.Title
+	entry.ChapterChars = countChars(content)
+	if result.BookTitle != "" {
+		entry.Title = result.BookTitle
+	}
+	catalog := s.catalog(entry.OriginURL, reader)
+	index := parser.ChapterIndex(catalog, url)
+	if index >= 0 {
+		entry.ChapterIndex, entry.ChapterTotal = index, len(catalog)
+	}
+	if err := historyManager.Save(entry); err != nil {
+		log.Printf("保存阅读记录失败: %v", err)
+	}
+
+	data := struct {
+		OriginURL  string
+		URL        string
+		Book       string
+		Title      string
+		Chapter    int
+		Total      int
+		Paragraphs []string
+		Start      int
+		Prev       string
+		Next       string
+	}{
+		OriginURL:  entry.OriginURL,
+		URL:        url,
+		Book:       bookName(entry),
+		Title:      result.Title,
+		Paragraphs: paragraphs,
+		Start:      start,
+		Prev:       reader.PrevURL(),
+		Next:       reader.NextURL(),
+	}
+	if index >= 0 {
+		data.Chapter, data.Total = index+1, len(catalog)
+	}
+	render(w, "read.html", data)
+}
+
+// allowedURL 只允许打开这本书的章节：上次读到的章节、前后章节或目录中的章节，
+// 避免通过 url 参数读取本机文件或访问其他站点。调用时需持有 mu
+func (s *webServer) allowedURL(entry utils.HistoryEntry, reader *parser.Reader, url string) (bool, error) {
+	if url == entry.LastURL {
+		return true, nil
+	}
+	if reader.GetUrl() == "" {
+		// 前后章节的地址来自章节页，先读取上次读到的章节
+		reader.SetUrl(entry.LastURL)
+		if _, err := reader.Read(); err != nil {
+			reader.SetUrl("")
+			return false, err
+		}
+	}
+	if url == reader.PrevURL() || url == reader.NextURL() {
+		return true, nil
+	}
+	return parser.ChapterIndex(s.catalog(entry.OriginURL, reader), url) >= 0, nil
+}
+
+// toc 目录页面
+func (s *webServer) toc(w http.ResponseWriter, r *http.Request) {
+	entry, ok := book(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reader, err := s.reader(entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, ok := s.catalogs[entry.OriginURL]; !ok && reader.GetUrl() == "" {
+		// 目录地址来自章节页，先读取当前章节
+		reader.SetUrl(entry.LastURL)
+		if _, err := reader.Read(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	chapters := s.catalog(entry.OriginURL, reader)
+	render(w, "toc.html", struct {
+		OriginURL string
+		Book      string
+		Chapters  []parser.Chapter
+		Current   int
+	}{entry.OriginURL, bookName(entry), chapters, parser.ChapterIndex(chapters, entry.LastURL)})
+}
+
+// progress 保存网页中的阅读位置
+func (s *webServer) progress(w http.ResponseWriter, r *http.Request) {
+	entry, ok := book(w, r)
+	if !ok {
+		return
+	}
+	paragraph, err := strconv.Atoi(r.FormValue("paragraph"))
+	if err != nil || paragraph < 0 {
+		http.Error(w, "paragraph 应为非负整数", http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("url") != entry.LastURL {
+		// 已经在别处换了章节
+		http.Error(w, "不是当前章节", http.StatusConflict)
+		return
+	}
+
+	s.mu.Lock()
+	reader := s.readers[entry.OriginURL]
+	var paragraphs []string
+	if reader != nil && reader.GetUrl() == entry.LastURL {
+		paragraphs = utils.SplitParagraphs(rules.Apply(reader.Current().Content))
+	}
+	s.mu.Unlock()
+
+	entry.Position = utils.NewPosition(paragraphs, paragraph, 0)
+	entry.Cursor = 0
+	if len(paragraphs) > 0 {
+		entry.Progress = float64(min(paragraph+1, len(paragraphs))) / float64(len(paragraphs)) * 100
+	}
+	if err := historyManager.Save(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runServe 处理 serve 命令，在局域网中提供网页阅读
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", config.Serve.Listen, "监听地址")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return errors.New("没有设置监听地址")
+	}
+
+	var err error
+	if rules, err = utils.LoadRules(config.Rules); err != nil {
+		return fmt.Errorf("读取过滤规则失败: %w", err)
+	}
+	if err := setupHttpClient(); err != nil {
+		return err
+	}
+
+	addr := *listen
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	fmt.Printf("网页阅读已启动: http://%s/\n", addr)
+	return http.ListenAndServe(*listen, newWebServer().handler())
+}
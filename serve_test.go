@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+)
+
+// pageParser 按 URL 返回预设的章节
+type pageParser map[string]parser.NovelResult
+
+func (p pageParser) ParseNovel(url string) (parser.NovelResult, error) {
+	return p[url], nil
+}
+
+func TestWebServer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	defer func() { historyManager = utils.NewHistoryManager() }()
+
+	book := "https://example.com/book/1.html"
+	historyManager.Save(utils.HistoryEntry{OriginURL: book, LastURL: book, Title: "测试书"})
+
+	s := newWebServer()
+	s.open = func(entry utils.HistoryEntry) (*parser.Reader, error) {
+		return parser.NewReaderWithParser(pageParser{
+			book:                              {Title: "第一章", Content: "第一段\n第二段<b>", Index: parser.IndexResult{Next: "2.html"}},
+			"https://example.com/book/2.html": {Title: "第二章", Content: "下一章", Index: parser.IndexResult{Prev: "1.html"}},
+		}), nil
+	}
+	handler := s.handler()
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s 返回 %d: %s", path, rec.Code, rec.Body)
+		}
+		return rec.Body.String()
+	}
+
+	if body := get("/"); !strings.Contains(body, "测试书") {
+		t.Errorf("书架应列出书名:\n%s", body)
+	}
+
+	body := get("/read?book=" + url.QueryEscape(book))
+	if !strings.Contains(body, "第二段&lt;b&gt;") || !strings.Contains(body, "url=https%3a%2f%2fexample.com%2fbook%2f2.html") {
+		t.Errorf("章节页应包含转义后的正文和下一页链接:\n%s", body)
+	}
+
+	form := url.Values{"book": {book}, "url": {book}, "paragraph": {"1"}}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/progress", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("保存进度失败: %d %s", rec.Code, rec.Body)
+	}
+	entry, _ := historyManager.Get(book)
+	if entry.Position.Paragraph != 1 || entry.Position.Fingerprint == "" || entry.Progress != 100 || entry.ChapterTitle != "第一章" {
+		t.Errorf("应与终端共用阅读位置，实际 %+v", entry)
+	}
+
+	get("/read?book=" + url.QueryEscape(book) + "&url=" + url.QueryEscape("https://example.com/book/2.html"))
+	entry, _ = historyManager.Get(book)
+	if entry.LastURL != "https://example.com/book/2.html" || entry.Position.Paragraph != 0 {
+		t.Errorf("换章后应从头开始，实际 %+v", entry)
+	}
+
+	for _, foreign := range []string{"/etc/passwd", "file:///etc/passwd", "http://127.0.0.1:8080/admin", "https://example.com/book/3.html"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/read?book="+url.QueryEscape(book)+"&url="+url.QueryEscape(foreign), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 不是这本书的章节，应返回 400，实际 %d", foreign, rec.Code)
+		}
+	}
+	entry, _ = historyManager.Get(book)
+	if entry.LastURL != "https://example.com/book/2.html" {
+		t.Errorf("被拒绝的地址不应保存，实际 %s", entry.LastURL)
+	}
+	get("/read?book=" + url.QueryEscape(book) + "&url=" + url.QueryEscape(book))
+
+	// 重启后第一次请求下一章，先读取上次的章节再核对地址
+	fresh := newWebServer()
+	fresh.open = s.open
+	rec = httptest.NewRecorder()
+	fresh.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/read?book="+url.QueryEscape(book)+"&url="+url.QueryEscape("https://example.com/book/2.html"), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("下一章应允许打开，实际 %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/read?book=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("书架中没有的书应返回 404，实际 %d", rec.Code)
+	}
+}
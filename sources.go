@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bookSearchMsg 各书源的搜索结果
+type bookSearchMsg struct {
+	query string
+	books []parser.BookResult
+	errs  []error
+}
+
+// tocMsg 书的目录
+type tocMsg struct {
+	book     parser.SearchResult
+	chapters []parser.Chapter
+	err      error
+}
+
+// configSources 配置中的书源
+func configSources() []parser.SearchSource {
+	sources := make([]parser.SearchSource, 0, len(config.Sources))
+	for _, s := range config.Sources {
+		name := s.Name
+		if name == "" {
+			name = s.Search
+		}
+		sources = append(sources, parser.SearchSource{
+			Name:     name,
+			URL:      s.Search,
+			Encoding: s.Encoding,
+			Results:  s.Results,
+			Title:    s.Title,
+			Author:   s.Author,
+			Link:     s.Link,
+			LinkURL:  s.LinkURL,
+			Timeout:  time.Duration(s.Timeout) * time.Second,
+		})
+	}
+	return sources
+}
+
+// searchSources 在所有书源中搜索书名或作者
+func (m *model) searchSources(query string) tea.Cmd {
+	m.state = "books"
+	if len(config.Sources) == 0 {
+		m.message = "没有配置书源"
+		return nil
+	}
+	m.bookQuery = query
+	m.books, m.searchErrors = nil, nil
+	m.bookSelected, m.bookSource = 0, 0
+	m.loading = true
+	m.message = ""
+	return fetchBooks(query)
+}
+
+// fetchBooks 在后台搜索
+func fetchBooks(query string) tea.Cmd {
+	return func() tea.Msg {
+		books, errs := parser.SearchBooks(httpClient, configSources(), query)
+		return bookSearchMsg{query: query, books: books, errs: errs}
+	}
+}
+
+// openTOC 在后台读取书的目录
+func (m *model) openTOC(book parser.SearchResult, back string) tea.Cmd {
+	m.loading = true
+	m.message = "正在读取目录: " + book.Source
+	m.tocReturn = back
+	return func() tea.Msg {
+		chapters, err := parser.NewGeneralParser(httpClient).ParseCatalog(book.URL)
+		if err == nil && len(chapters) == 0 {
+			err = parser.ErrNoCatalog
+		}
+		return tocMsg{book: book, chapters: chapters, err: err}
+	}
+}
+
+// updateBooks 书源搜索结果的按键处理
+func (m model) updateBooks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case bookSearchMsg:
+		if msg.query != m.bookQuery || !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		m.books, m.searchErrors = msg.books, msg.errs
+		if len(m.books) == 0 {
+			m.message = "没有找到: " + msg.query
+		}
+		return m, nil
+	case tocMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("读取目录失败: %v", msg.err)
+			return m, nil
+		}
+		m.message = ""
+		m.showTOC(msg.book, msg.chapters)
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keyMsg.String() == "ctrl+c" || keyMsg.String() == "q":
+		m.done = true
+		return m, tea.Quit
+	case keyMsg.String() == "esc":
+		m.loading = false
+		m.message = ""
+		m.state = "library"
+	case m.loading:
+	case keyMsg.String() == "tab":
+		if len(m.books) > 0 {
+			m.bookSource = (m.bookSource + 1) % len(m.books[m.bookSelected].Sources)
+		}
+	case key.Matches(keyMsg, keys.Search):
+		return m, m.startInput("sourceSearch", "搜索书源：", "书名或作者")
+	case keyMsg.String() == "enter":
+		if len(m.books) == 0 {
+			break
+		}
+		return m, m.openTOC(m.books[m.bookSelected].Sources[m.bookSource], "books")
+	case key.Matches(keyMsg, keys.Down):
+		if m.bookSelected < len(m.books)-1 {
+			m.bookSelected++
+			m.bookSource = 0
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.bookSelected > 0 {
+			m.bookSelected--
+			m.bookSource = 0
+		}
+	case key.Matches(keyMsg, keys.Top):
+		m.bookSelected, m.bookSource = 0, 0
+	case key.Matches(keyMsg, keys.Bottom):
+		m.bookSelected, m.bookSource = max(len(m.books)-1, 0), 0
+	}
+	return m, nil
+}
+
+// viewBooks 书源搜索结果界面
+func (m model) viewBooks() string {
+	var b strings.Builder
+	b.WriteString("搜索书源: " + m.bookQuery + "\n\n")
+
+	rows := len(m.books)
+	if m.height > 5 && rows > m.height-5 {
+		rows = m.height - 5
+	}
+	start := 0
+	if m.bookSelected >= rows {
+		start = m.bookSelected - rows + 1
+	}
+	for i := start; i < start+rows && i < len(m.books); i++ {
+		book := m.books[i]
+		cursor := "  "
+		if i == m.bookSelected {
+			cursor = "> "
+		}
+		names := make([]string, len(book.Sources))
+		for j, source := range book.Sources {
+			names[j] = source.Source
+			if i == m.bookSelected && j == m.bookSource && len(book.Sources) > 1 {
+				names[j] = "[" + source.Source + "]"
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s%s\t%s\t%s\n", cursor, book.Title, book.Author, strings.Join(names, " ")))
+	}
+
+	if m.loading && m.message == "" {
+		b.WriteString("搜索中...\n")
+	}
+	if len(m.searchErrors) > 0 {
+		errs := make([]string, len(m.searchErrors))
+		for i, err := range m.searchErrors {
+			errs[i] = err.Error()
+		}
+		b.WriteString(helpStyle.Render("未返回结果: "+strings.Join(errs, "；")) + "\n")
+	}
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 目录\ttab 切换书源\t" + keys.Search.Help().Key + " 搜索\tesc 返回\tq 退出"))
+	return b.String()
+}
+
+// showTOC 显示目录，选中上次读到的章节
+func (m *model) showTOC(book parser.SearchResult, chapters []parser.Chapter) {
+	m.tocBook, m.toc = book, chapters
+	m.tocSelected = 0
+	if entry, ok := historyManager.Get(chapters[0].URL); ok {
+		m.tocSelected = max(parser.ChapterIndex(chapters, entry.LastURL), 0)
+	}
+	m.state = "toc"
+}
+
+// openChapter 打开目录中的第 i 章。阅读记录以第一章的地址区分每本书
+func (m *model) openChapter(i int) tea.Cmd {
+	chapter := m.toc[i]
+	origin := m.toc[0].URL
+	entry, ok := historyManager.Get(origin)
+	if !ok {
+		entry = utils.HistoryEntry{OriginURL: origin, Title: m.tocBook.Title, Convert: config.Convert}
+	}
+	if entry.LastURL != chapter.URL {
+		entry.LastURL = chapter.URL
+		entry.Cursor, entry.Position, entry.Progress = 0, utils.Position{}, 0
+	}
+	entry.ChapterIndex, entry.ChapterTotal = i, len(m.toc)
+	cmd := m.openEntry(entry)
+	m.catalog, m.catalogLoaded = m.toc, true
+	return cmd
+}
+
+// updateTOC 目录界面的按键处理
+func (m model) updateTOC(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keyMsg.String() == "ctrl+c" || keyMsg.String() == "q":
+		m.done = true
+		return m, tea.Quit
+	case keyMsg.String() == "esc":
+		m.message = ""
+		m.state = m.tocReturn
+	case keyMsg.String() == "enter":
+		if m.tocReturn == "switch" {
+			// 换源时手动选择的章节
+			return m, m.switchTo(m.tocBook, m.toc, m.tocSelected)
+		}
+		return m, m.openChapter(m.tocSelected)
+	case key.Matches(keyMsg, keys.Down):
+		if m.tocSelected < len(m.toc)-1 {
+			m.tocSelected++
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.tocSelected > 0 {
+			m.tocSelected--
+		}
+	case key.Matches(keyMsg, keys.PageDown):
+		m.tocSelected = min(m.tocSelected+max(m.height-4, 1), len(m.toc)-1)
+	case key.Matches(keyMsg, keys.PageUp):
+		m.tocSelected = max(m.tocSelected-max(m.height-4, 1), 0)
+	case key.Matches(keyMsg, keys.Top):
+		m.tocSelected = 0
+	case key.Matches(keyMsg, keys.Bottom):
+		m.tocSelected = len(m.toc) - 1
+	}
+	return m, nil
+}
+
+// viewTOC 目录界面
+func (m model) viewTOC() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s\t%s\t%s（共 %d 章）\n\n", m.tocBook.Title, m.tocBook.Author, m.tocBook.Source, len(m.toc)))
+
+	rows := len(m.toc)
+	if m.height > 4 && rows > m.height-4 {
+		rows = m.height - 4
+	}
+	start := 0
+	if m.tocSelected >= rows {
+		start = m.tocSelected - rows + 1
+	}
+	for i := start; i < start+rows && i < len(m.toc); i++ {
+		cursor := "  "
+		if i == m.tocSelected {
+			cursor = "> "
+		}
+		b.WriteString(cursor + m.toc[i].Title + "\n")
+	}
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 阅读\tesc 返回\tq 退出"))
+	return b.String()
+}
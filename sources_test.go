@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSearchSources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	defer func() {
+		historyManager = utils.NewHistoryManager()
+		config = utils.DefaultConfig()
+	}()
+	config.Sources = []utils.SourceConfig{{Name: "A"}, {Name: "B"}}
+
+	m := model{state: "library"}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = updated.(model)
+	if m.state != "input" || m.inputAction != "sourceSearch" {
+		t.Fatalf("应开始输入关键字，实际 %s %s", m.state, m.inputAction)
+	}
+	m.textInput.SetValue("遮天")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "books" || !m.loading || cmd == nil {
+		t.Fatalf("应开始搜索，实际 %s", m.state)
+	}
+
+	// 旧的搜索结果应被忽略
+	updated, _ = m.Update(bookSearchMsg{query: "别的书"})
+	m = updated.(model)
+	if !m.loading {
+		t.Fatal("不应处理其他关键字的结果")
+	}
+	book := parser.BookResult{Title: "遮天", Author: "辰东", Sources: []parser.SearchResult{
+		{Source: "A", Title: "遮天", URL: "https://a.example.com/book/1/"},
+		{Source: "B", Title: "遮天", URL: "https://b.example.com/book/8/"},
+	}}
+	updated, _ = m.Update(bookSearchMsg{query: "遮天", books: []parser.BookResult{book}})
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(model)
+	if m.bookSource != 1 {
+		t.Fatalf("tab 应切换书源，实际 %d", m.bookSource)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if !m.loading || cmd == nil {
+		t.Fatal("应读取选中书源的目录")
+	}
+
+	chapters := []parser.Chapter{
+		{Title: "第一章", URL: "https://b.example.com/book/8/1.html"},
+		{Title: "第二章", URL: "https://b.example.com/book/8/2.html"},
+	}
+	historyManager.Save(utils.HistoryEntry{OriginURL: chapters[0].URL, LastURL: chapters[1].URL, Cursor: 5})
+	updated, _ = m.Update(tocMsg{book: book.Sources[1], chapters: chapters})
+	m = updated.(model)
+	if m.state != "toc" || m.tocSelected != 1 {
+		t.Fatalf("应显示目录并选中上次读到的章节，实际 %s %d", m.state, m.tocSelected)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "reading" || cmd == nil || m.originUrl != chapters[0].URL || reader.GetUrl() != chapters[1].URL {
+		t.Fatalf("应打开选中的章节，实际 %s %s", m.state, reader.GetUrl())
+	}
+	if m.cursor != 5 || m.chapterIndex != 1 || m.chapterTotal != 2 || len(m.catalog) != 2 {
+		t.Errorf("应从上次的位置继续，实际 cursor=%d chapter=%d/%d", m.cursor, m.chapterIndex, m.chapterTotal)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// speaker 朗读使用的后端
+var speaker utils.Speaker
+
+// spokenMsg 一句话读完，id 为开始朗读时的序号
+type spokenMsg struct {
+	id  int
+	err error
+}
+
+// newSpeaker 根据配置创建朗读后端，设置了输出目录时写入 WAV 文件
+func newSpeaker(tts utils.TTSConfig) utils.Speaker {
+	if tts.Output != "" {
+		return &utils.FileSpeaker{Command: tts.FileCommand, Dir: tts.Output}
+	}
+	return &utils.CommandSpeaker{Command: tts.Command}
+}
+
+// closeSpeaker 退出时关闭写入中的音频文件
+func closeSpeaker() {
+	if c, ok := speaker.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// startSpeaking 从当前位置开始朗读
+func (m *model) startSpeaking() tea.Cmd {
+	if len(m.content) == 0 {
+		return nil
+	}
+	m.speaking = true
+	m.speakID++
+	m.loadSentences()
+	m.sentence = len(m.sentences)
+	for i, s := range m.sentences {
+		if utils.FindLine(m.content, s.Paragraph, s.Offset) >= m.cursor {
+			m.sentence = i
+			break
+		}
+	}
+	return m.speakCurrent()
+}
+
+// loadSentences 把本章分成句子，按章节保存时开始新的一章
+func (m *model) loadSentences() {
+	m.sentences = utils.SplitSentences(utils.SplitParagraphs(m.raw))
+	m.sentence = 0
+	if s, ok := speaker.(utils.ChapterSpeaker); ok {
+		if err := s.StartChapter(m.chapterIndex+1, reader.GetTitle()); err != nil {
+			m.message = fmt.Sprintf("朗读失败: %v", err)
+		}
+	}
+}
+
+// stopSpeaking 停止朗读，正在读的一句会被打断
+func (m *model) stopSpeaking() {
+	m.speaking = false
+	if m.cancelSpeak != nil {
+		m.cancelSpeak()
+		m.cancelSpeak = nil
+	}
+}
+
+// speakCurrent 把光标移到当前句子并朗读，本章读完后继续下一章
+func (m *model) speakCurrent() tea.Cmd {
+	if m.sentence >= len(m.sentences) {
+		if reader.HasNext() {
+			m.cursor = 0
+			return func() tea.Msg {
+				return m.fetchNovelContent("down")
+			}
+		}
+		m.stopSpeaking()
+		m.message = "已是最新章节，朗读已停止"
+		return nil
+	}
+
+	s := m.sentences[m.sentence]
+	if line := utils.FindLine(m.content, s.Paragraph, s.Offset); line < m.cursor || line >= m.cursor+m.lines {
+		m.cursor = min(line, max(len(m.content)-m.lines, 0))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSpeak = cancel
+	id := m.speakID
+	return func() tea.Msg {
+		return spokenMsg{id: id, err: speaker.Speak(ctx, s.Text)}
+	}
+}
+
+// spoken 一句读完后读下一句
+func (m model) spoken(msg spokenMsg) (tea.Model, tea.Cmd) {
+	if !m.speaking || msg.id != m.speakID {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.stopSpeaking()
+		m.message = fmt.Sprintf("朗读失败: %v", msg.err)
+		return m, nil
+	}
+	m.sentence++
+	return m, m.speakCurrent()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"novel-reader-go/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubSpeaker 记录朗读的文字，立即返回
+type stubSpeaker struct {
+	texts    []string
+	chapters []string
+}
+
+func (s *stubSpeaker) Speak(ctx context.Context, text string) error {
+	s.texts = append(s.texts, text)
+	return nil
+}
+
+func (s *stubSpeaker) StartChapter(index int, title string) error {
+	s.chapters = append(s.chapters, title)
+	return nil
+}
+
+func TestSpeak(t *testing.T) {
+	stub := &stubSpeaker{}
+	speaker = stub
+	reader = parser.NewReaderWithoutUrl()
+	m := model{state: "reading", lines: 1}
+	m.setContent("第一句。第二句！\n第三句")
+	m.cursor = 1
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(model)
+	if !m.speaking || cmd == nil {
+		t.Fatal("应开始朗读")
+	}
+	if len(stub.chapters) != 1 {
+		t.Error("开始朗读时应开始新的一章")
+	}
+
+	// 从光标所在的行开始朗读
+	updated, cmd = m.Update(cmd())
+	m = updated.(model)
+	if len(stub.texts) != 1 || stub.texts[0] != "第三句" {
+		t.Errorf("应从光标所在的行开始朗读，实际 %v", stub.texts)
+	}
+	if m.speaking || cmd != nil {
+		t.Error("没有下一章时应停止朗读")
+	}
+
+	m.cursor = 0
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(model)
+	updated, cmd = m.Update(cmd())
+	m = updated.(model)
+	if stub.texts[len(stub.texts)-1] != "第一句。" || cmd == nil {
+		t.Fatalf("应从第一句开始朗读，实际 %v", stub.texts)
+	}
+	if m.sentence != 1 || m.cursor != 0 {
+		t.Errorf("第二句在第一行，光标不应移动，实际 cursor=%d", m.cursor)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = updated.(model)
+	if m.speaking {
+		t.Error("按其他键应停止朗读")
+	}
+	if _, cmd = m.Update(spokenMsg{id: m.speakID}); cmd != nil {
+		t.Error("停止后应忽略遗留的朗读结果")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"unicode"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// idleTimeout 两次按键间隔超过这个时间时不计入阅读时长
+	idleTimeout = 5 * time.Minute
+	// flushInterval 阅读统计写入历史记录的间隔
+	flushInterval = time.Minute
+)
+
+// catalogMsg 目录解析完成，reader 为解析时的 reader
+type catalogMsg struct {
+	reader   *parser.Reader
+	chapters []parser.Chapter
+}
+
+// loadCatalog 在后台解析目录，用于计算全书进度
+func (m *model) loadCatalog() tea.Cmd {
+	if m.catalogLoaded {
+		return nil
+	}
+	m.catalogLoaded = true
+	r := reader
+	return func() tea.Msg {
+		chapters, err := r.Catalog()
+		if err != nil {
+			return catalogMsg{reader: r}
+		}
+		return catalogMsg{reader: r, chapters: chapters}
+	}
+}
+
+// setCatalog 根据目录更新当前章节的位置
+func (m *model) setCatalog(chapters []parser.Chapter) {
+	if len(chapters) == 0 {
+		return
+	}
+	m.catalog = chapters
+	m.chapterTotal = len(chapters)
+	m.locateChapter()
+}
+
+// locateChapter 在目录中查找当前章节，分页的章节找不到时保持不变
+func (m *model) locateChapter() {
+	if i := parser.ChapterIndex(m.catalog, reader.GetUrl()); i >= 0 {
+		m.chapterIndex = i
+	}
+}
+
+// countChars 统计字数，不含空白
+func countChars(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// track 记录阅读时长，以及按键前屏幕上显示过的文字
+func (m *model) track() {
+	now := time.Now()
+	if !m.lastActive.IsZero() {
+		if d := now.Sub(m.lastActive); d < idleTimeout {
+			m.pendingTime += d
+		}
+	}
+	m.lastActive = now
+
+	end := min(m.cursor+m.lines, len(m.content))
+	if end > m.readTo {
+		for i := max(m.readTo, m.cursor); i < end; i++ {
+			m.pendingChars += countChars(m.content[i].Text)
+		}
+		m.readTo = end
+	}
+
+	if m.pendingTime >= flushInterval {
+		m.flushStats()
+	}
+}
+
+// flushStats 把累计的阅读时长和字数写入历史记录
+func (m *model) flushStats() {
+	seconds := int(m.pendingTime / time.Second)
+	if seconds == 0 && m.pendingChars == 0 {
+		return
+	}
+	err := historyManager.AddReading(m.originUrl, utils.Reading{
+		Time:       time.Now(),
+		Seconds:    seconds,
+		Chars:      m.pendingChars,
+		NewSession: !m.sessionStarted,
+	})
+	if err != nil {
+		return
+	}
+	m.pendingTime -= time.Duration(seconds) * time.Second
+	m.pendingChars = 0
+	m.sessionStarted = true
+}
+
+// bookProgress 状态栏中的全书进度
+func (m model) bookProgress() string {
+	if m.chapterTotal <= 1 {
+		return ""
+	}
+	entry := utils.HistoryEntry{ChapterIndex: m.chapterIndex, ChapterTotal: m.chapterTotal, Progress: m.progress()}
+	return fmt.Sprintf("第%d/%d章 %.1f%%\t", m.chapterIndex+1, m.chapterTotal, entry.BookProgress())
+}
+
+// formatDuration 显示为“1 小时 5 分钟”
+func formatDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	switch {
+	case minutes == 0:
+		return "不到 1 分钟"
+	case minutes < 60:
+		return fmt.Sprintf("%d 分钟", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d 小时", minutes/60)
+	}
+	return fmt.Sprintf("%d 小时 %d 分钟", minutes/60, minutes%60)
+}
+
+// total 统计所有书在 [from, to) 之间的阅读量
+func total(library utils.Library, from time.Time, to time.Time) utils.DayStats {
+	var sum utils.DayStats
+	for _, book := range library.Books {
+		stats := book.Stats.Between(from, to)
+		sum.Seconds += stats.Seconds
+		sum.Chars += stats.Chars
+	}
+	return sum
+}
+
+// printStats 输出每日、每周的阅读量和每本书的统计
+func printStats(w io.Writer, library utils.Library, now time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// 每周从周一开始
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	line := func(name string, stats utils.DayStats) {
+		fmt.Fprintf(w, "%s\t%s\t%d 字\n", name, formatDuration(time.Duration(stats.Seconds)*time.Second), stats.Chars)
+	}
+	line("今天", total(library, today, tomorrow))
+	line("本周", total(library, week, tomorrow))
+
+	fmt.Fprintln(w, "\n最近 7 天")
+	weekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+	for i := 6; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		line(day.Format("01-02")+" "+weekdays[day.Weekday()], total(library, day, day.AddDate(0, 0, 1)))
+	}
+
+	fmt.Fprintln(w, "\n书架")
+	for _, book := range library.Books {
+		title := book.Title
+		if title == "" {
+			title = book.OriginURL
+		}
+		progress := "进度未知"
+		if p := book.BookProgress(); p >= 0 {
+			progress = fmt.Sprintf("第%d/%d章 %.1f%%", book.ChapterIndex+1, book.ChapterTotal, p)
+		}
+		stats := book.Stats
+		remaining := "剩余时间未知"
+		if d := book.Remaining(); d > 0 {
+			remaining = "预计还需 " + formatDuration(d)
+		}
+		fmt.Fprintf(w, "%s\t%s\t共 %s，%d 次\t%.0f 字/分钟\t%s\n",
+			title, progress, formatDuration(time.Duration(stats.Seconds)*time.Second), stats.Sessions, stats.Speed(), remaining)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"novel-reader-go/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// host 地址的主机名，用来排除当前书源
+func host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// startSwitch 按书名在其他书源中搜索这本书
+func (m *model) startSwitch() tea.Cmd {
+	if len(config.Sources) == 0 {
+		m.message = "没有配置书源"
+		return nil
+	}
+	if len(m.content) == 0 {
+		return nil
+	}
+	raw := reader.Raw()
+	title := raw.BookTitle
+	if title == "" {
+		entry, _ := historyManager.Get(m.originUrl)
+		title = entry.Title
+	}
+	if title == "" {
+		m.message = "不知道书名，无法换源"
+		return nil
+	}
+
+	m.selecting = false
+	m.switchEntry = m.historyEntry()
+	m.switchTitle = raw.Title
+	m.switchCandidates, m.switchSelected = nil, 0
+	m.books = nil
+	m.bookQuery = title
+	m.loading = true
+	m.message = ""
+	m.state = "switch"
+	return fetchBooks(title)
+}
+
+// switchCandidates 搜索结果中同一本书的其他书源
+func switchCandidates(books []parser.BookResult, title string, author string, current string) []parser.SearchResult {
+	var candidates []parser.SearchResult
+	for _, book := range books {
+		if !parser.SameBook(title, author, book.Title, book.Author) {
+			continue
+		}
+		for _, source := range book.Sources {
+			if host(source.URL) != host(current) {
+				candidates = append(candidates, source)
+			}
+		}
+	}
+	return candidates
+}
+
+// switchTo 换到另一个书源的第 i 章，阅读记录和阅读位置保持不变
+func (m *model) switchTo(book parser.SearchResult, chapters []parser.Chapter, i int) tea.Cmd {
+	entry := m.switchEntry
+	entry.LastURL = chapters[i].URL
+	entry.Source = ""
+	entry.ChapterIndex, entry.ChapterTotal = i, len(chapters)
+	if err := historyManager.Save(entry); err != nil {
+		m.message = fmt.Sprintf("保存阅读记录失败: %v", err)
+		m.state = "reading"
+		return nil
+	}
+	cmd := m.openEntry(entry)
+	m.catalog, m.catalogLoaded = chapters, true
+	m.message = fmt.Sprintf("已换源: %s %s", book.Source, chapters[i].Title)
+	return cmd
+}
+
+// switchHint 当前章节在新目录中的大致位置，目录长度不同时按比例换算
+func (m model) switchHint(total int) int {
+	if m.chapterTotal > 0 {
+		return m.chapterIndex * total / m.chapterTotal
+	}
+	return m.chapterIndex
+}
+
+// updateSwitch 换源界面的按键处理
+func (m model) updateSwitch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case bookSearchMsg:
+		if msg.query != m.bookQuery || !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		raw := reader.Raw()
+		m.switchCandidates = switchCandidates(msg.books, m.bookQuery, raw.Author, reader.GetUrl())
+		if len(m.switchCandidates) == 0 {
+			m.message = fmt.Sprintf("其他书源中没有找到《%s》", m.bookQuery)
+		}
+		return m, nil
+	case tocMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.message = fmt.Sprintf("读取目录失败: %v", msg.err)
+			return m, nil
+		}
+		hint := m.switchHint(len(msg.chapters))
+		if i := parser.MatchChapter(msg.chapters, m.switchTitle, hint); i >= 0 {
+			return m, m.switchTo(msg.book, msg.chapters, i)
+		}
+		// 对不上时在目录中手动选择
+		m.showTOC(msg.book, msg.chapters)
+		m.tocSelected = min(hint, len(msg.chapters)-1)
+		m.message = fmt.Sprintf("没有找到“%s”，请在目录中选择", m.switchTitle)
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keyMsg.String() == "ctrl+c":
+		m.done = true
+		m.flushStats()
+		historyManager.Save(m.historyEntry())
+		return m, tea.Quit
+	case keyMsg.String() == "q" || keyMsg.String() == "esc":
+		m.loading = false
+		m.message = ""
+		m.state = "reading"
+	case m.loading:
+	case keyMsg.String() == "enter":
+		if len(m.switchCandidates) == 0 {
+			break
+		}
+		return m, m.openTOC(m.switchCandidates[m.switchSelected], "switch")
+	case key.Matches(keyMsg, keys.Down):
+		if m.switchSelected < len(m.switchCandidates)-1 {
+			m.switchSelected++
+		}
+	case key.Matches(keyMsg, keys.Up):
+		if m.switchSelected > 0 {
+			m.switchSelected--
+		}
+	}
+	return m, nil
+}
+
+// viewSwitch 换源界面
+func (m model) viewSwitch() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("换源: 《%s》 %s\n\n", m.bookQuery, m.switchTitle))
+	for i, source := range m.switchCandidates {
+		cursor := "  "
+		if i == m.switchSelected {
+			cursor = "> "
+		}
+		b.WriteString(fmt.Sprintf("%s%s\t%s\t%s\n", cursor, source.Source, source.Author, host(source.URL)))
+	}
+	if m.loading && m.message == "" {
+		b.WriteString("搜索中...\n")
+	}
+	if m.message != "" {
+		b.WriteString(m.message + "\n")
+	}
+	b.WriteString(helpStyle.Render(listHelp() + "\tenter 换到这个书源\tq 返回"))
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"novel-reader-go/parser"
+	"novel-reader-go/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSwitchSource(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	historyManager = utils.NewHistoryManager()
+	defer func() {
+		historyManager = utils.NewHistoryManager()
+		config = utils.DefaultConfig()
+	}()
+	config.Sources = []utils.SourceConfig{{Name: "A"}, {Name: "B"}}
+
+	origin := "https://a.example.com/book/1/1.html"
+	current := "https://a.example.com/book/1/2.html"
+	reader = parser.NewReaderWithParser(pageParser{
+		current: {Title: "第二章 下山", BookTitle: "遮天", Author: "辰东", Content: "第一段\n第二段\n第三段"},
+	})
+	reader.SetUrl(current)
+	if _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	old := reader
+	m := model{state: "reading", lines: 1, originUrl: origin, chapterIndex: 1, chapterTotal: 3}
+	m.setContent(reader.Current().Content)
+	m.cursor = 1
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = updated.(model)
+	if m.state != "switch" || m.bookQuery != "遮天" || cmd == nil {
+		t.Fatalf("应按书名搜索其他书源，实际 %s %q", m.state, m.bookQuery)
+	}
+
+	updated, _ = m.Update(bookSearchMsg{query: "遮天", books: []parser.BookResult{
+		{Title: "遮天", Author: "辰东", Sources: []parser.SearchResult{
+			{Source: "A", Title: "遮天", Author: "辰东", URL: "https://www.a.example.com/book/1/"},
+			{Source: "B", Title: "遮天", Author: "辰东", URL: "https://b.example.com/book/8/"},
+		}},
+		{Title: "遮天", Author: "别人", Sources: []parser.SearchResult{
+			{Source: "B", Title: "遮天", Author: "别人", URL: "https://b.example.com/book/9/"},
+		}},
+	}})
+	m = updated.(model)
+	if len(m.switchCandidates) != 1 || m.switchCandidates[0].URL != "https://b.example.com/book/8/" {
+		t.Fatalf("应排除当前书源和作者不同的书，实际 %+v", m.switchCandidates)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if !m.loading {
+		t.Fatal("应读取新书源的目录")
+	}
+	chapters := []parser.Chapter{
+		{Title: "第1章 出山", URL: "https://b.example.com/book/8/1.html"},
+		{Title: "第2章 下山（求月票）", URL: "https://b.example.com/book/8/2.html"},
+		{Title: "第3章 江湖", URL: "https://b.example.com/book/8/3.html"},
+	}
+	updated, cmd = m.Update(tocMsg{book: m.switchCandidates[0], chapters: chapters})
+	m = updated.(model)
+	if m.state != "reading" || cmd == nil || reader.GetUrl() != chapters[1].URL {
+		t.Fatalf("应换到新书源的对应章节，实际 %s %s", m.state, reader.GetUrl())
+	}
+	if m.originUrl != origin || m.restore == nil || m.restore.Paragraph != 1 {
+		t.Errorf("应保留阅读记录和阅读位置，实际 %s %+v", m.originUrl, m.restore)
+	}
+	entry, _ := historyManager.Get(origin)
+	if entry.LastURL != chapters[1].URL || entry.ChapterIndex != 1 || entry.ChapterTotal != 3 {
+		t.Errorf("阅读记录应指向新书源，实际 %+v", entry)
+	}
+
+	// 原书源的目录在换源后才解析完成
+	updated, _ = m.Update(catalogMsg{reader: old, chapters: make([]parser.Chapter, 10)})
+	m = updated.(model)
+	if len(m.catalog) != 3 || m.chapterTotal != 3 || m.chapterIndex != 1 {
+		t.Errorf("原书源的目录不应覆盖新书源的目录，实际 %d 章 %d/%d", len(m.catalog), m.chapterIndex, m.chapterTotal)
+	}
+
+	// 对不上时打开目录手动选择
+	m.state = "switch"
+	m.switchTitle = "番外 风起"
+	m.loading = true
+	updated, _ = m.Update(tocMsg{book: m.switchCandidates[0], chapters: chapters})
+	m = updated.(model)
+	if m.state != "toc" || m.tocReturn != "switch" {
+		t.Fatalf("应打开目录，实际 %s", m.state)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != "reading" || m.originUrl != origin {
+		t.Errorf("在目录中选择后应换源继续阅读，实际 %s %s", m.state, m.originUrl)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"novel-reader-go/utils"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme 阅读界面的配色
+type theme struct {
+	text      lipgloss.Style
+	dialogue  lipgloss.Style // 引号中的对话
+	status    lipgloss.Style
+	highlight lipgloss.Style
+	selection lipgloss.Style
+	search    lipgloss.Style
+}
+
+// themes 内置主题
+var themes = map[string]theme{
+	"default": {
+		status:    lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		highlight: lipgloss.NewStyle().Underline(true),
+		selection: lipgloss.NewStyle().Reverse(true),
+		search:    lipgloss.NewStyle().Background(lipgloss.Color("3")).Foreground(lipgloss.Color("0")),
+	},
+	"dark": {
+		text:      lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
+		dialogue:  lipgloss.NewStyle().Foreground(lipgloss.Color("180")),
+		status:    lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
+		highlight: lipgloss.NewStyle().Foreground(lipgloss.Color("229")).Underline(true),
+		selection: lipgloss.NewStyle().Background(lipgloss.Color("238")),
+		search:    lipgloss.NewStyle().Background(lipgloss.Color("58")).Foreground(lipgloss.Color("230")),
+	},
+	"light": {
+		text:      lipgloss.NewStyle().Foreground(lipgloss.Color("235")),
+		dialogue:  lipgloss.NewStyle().Foreground(lipgloss.Color("25")),
+		status:    lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+		highlight: lipgloss.NewStyle().Foreground(lipgloss.Color("124")).Underline(true),
+		selection: lipgloss.NewStyle().Background(lipgloss.Color("153")),
+		search:    lipgloss.NewStyle().Background(lipgloss.Color("229")).Foreground(lipgloss.Color("0")),
+	},
+	"sepia": {
+		text:      lipgloss.NewStyle().Foreground(lipgloss.Color("#704214")),
+		dialogue:  lipgloss.NewStyle().Foreground(lipgloss.Color("#a0522d")),
+		status:    lipgloss.NewStyle().Foreground(lipgloss.Color("#a08060")),
+		highlight: lipgloss.NewStyle().Foreground(lipgloss.Color("#8b0000")).Underline(true),
+		selection: lipgloss.NewStyle().Reverse(true),
+		search:    lipgloss.NewStyle().Background(lipgloss.Color("#f0d890")).Foreground(lipgloss.Color("#000000")),
+	},
+	"terminal": {
+		text:      lipgloss.NewStyle().Foreground(lipgloss.Color("34")),
+		dialogue:  lipgloss.NewStyle().Foreground(lipgloss.Color("46")),
+		status:    lipgloss.NewStyle().Foreground(lipgloss.Color("22")),
+		highlight: lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Underline(true),
+		selection: lipgloss.NewStyle().Reverse(true),
+		search:    lipgloss.NewStyle().Background(lipgloss.Color("22")).Foreground(lipgloss.Color("46")),
+	},
+}
+
+var (
+	textStyle     = lipgloss.NewStyle()
+	dialogueStyle = lipgloss.NewStyle()
+)
+
+// themeNames 按名称排序的主题列表，default 在最前
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		if name != "default" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{"default"}, names...)
+}
+
+// applyTheme 使用主题，dim 时降低文字亮度
+func applyTheme(name string, dim bool) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("未知的主题: %s，可选: %s", name, strings.Join(themeNames(), "、"))
+	}
+	textStyle = t.text.Faint(dim)
+	dialogueStyle = t.dialogue.Faint(dim)
+	helpStyle = t.status.Faint(dim)
+	highlightStyle = t.highlight
+	selectStyle = t.selection
+	searchStyle = t.search
+	return nil
+}
+
+// nextTheme 切换到下一个主题并保存到配置文件
+func (m *model) nextTheme() {
+	names := themeNames()
+	next := names[0]
+	for i, name := range names {
+		if name == config.Theme {
+			next = names[(i+1)%len(names)]
+		}
+	}
+	config.Theme = next
+	applyTheme(config.Theme, config.Dim)
+	m.message = "主题: " + next
+	m.saveDisplay()
+}
+
+// toggleDim 切换暗淡模式并保存到配置文件
+func (m *model) toggleDim() {
+	config.Dim = !config.Dim
+	applyTheme(config.Theme, config.Dim)
+	m.message = "暗淡模式已关闭"
+	if config.Dim {
+		m.message = "暗淡模式已开启"
+	}
+	m.saveDisplay()
+}
+
+// saveDisplay 保存主题和暗淡模式
+func (m *model) saveDisplay() {
+	err := utils.UpdateConfig(map[string]interface{}{
+		"theme": config.Theme,
+		"dim":   config.Dim,
+	})
+	if err != nil {
+		m.message = fmt.Sprintf("保存配置失败: %v", err)
+	}
+}
+
+// leftMargin 正文左侧的空白列数，居中时让正文位于终端中间
+func (m model) leftMargin() int {
+	margin := config.Margin
+	if config.Center && m.width > 0 {
+		margin = max(margin, (m.width-m.wrapWidth())/2)
+	}
+	return margin
+}
+
+// quoteState 扫描文字中的引号，返回每个字符是否属于对话以及结束时是否仍在引号中
+func quoteState(runes []rune, inQuote bool) ([]bool, bool) {
+	marked := make([]bool, len(runes))
+	for i, r := range runes {
+		switch r {
+		case '“', '「', '『':
+			inQuote = true
+			marked[i] = true
+		case '”', '」', '』':
+			marked[i] = true
+			inQuote = false
+		case '"':
+			marked[i] = true
+			inQuote = !inQuote
+		default:
+			marked[i] = inQuote
+		}
+	}
+	return marked, inQuote
+}
+
+// renderText 按主题渲染一行正文，引号中的对话使用单独的颜色
+func (m model) renderText(i int, paragraphs []string) string {
+	line := m.content[i]
+	inQuote := false
+	if line.Paragraph < len(paragraphs) {
+		prefix := []rune(paragraphs[line.Paragraph])
+		_, inQuote = quoteState(prefix[:min(line.Offset, len(prefix))], false)
+	}
+
+	runes := []rune(line.Text)
+	marked, _ := quoteState(runes, inQuote)
+	var b strings.Builder
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && marked[end] == marked[start] {
+			end++
+		}
+		style := textStyle
+		if marked[start] {
+			style = dialogueStyle
+		}
+		b.WriteString(style.Render(string(runes[start:end])))
+		start = end
+	}
+	return b.String()
+}
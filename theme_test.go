@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestQuoteState(t *testing.T) {
+	marked, inQuote := quoteState([]rune("他说：“走吧"), false)
+	if !inQuote {
+		t.Error("引号未结束时应仍在对话中")
+	}
+	expected := []bool{false, false, false, true, true, true}
+	for i := range expected {
+		if marked[i] != expected[i] {
+			t.Errorf("第 %d 个字符期望 %v，实际 %v", i, expected[i], marked[i])
+		}
+	}
+
+	// 上一行未结束的对话延续到下一行
+	marked, inQuote = quoteState([]rune("。”她笑了"), true)
+	if inQuote || !marked[0] || !marked[1] || marked[2] {
+		t.Errorf("对话结束位置有误: %v %v", marked, inQuote)
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	for _, name := range themeNames() {
+		if err := applyTheme(name, true); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := applyTheme("none", false); err == nil {
+		t.Error("未知的主题应返回错误")
+	}
+	applyTheme("default", false)
+}
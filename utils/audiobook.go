@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AudioTags 音频文件的标题、作者和书名
+type AudioTags struct {
+	Title  string
+	Artist string
+	Album  string
+}
+
+// EncodeOgg 调用命令把 WAV 转换为 OGG，命令中的 {input}、{output} 替换为输入输出文件，
+// {title}、{artist}、{album} 替换为写入 Vorbis 注释的标签
+func EncodeOgg(ctx context.Context, command []string, input string, output string, tags AudioTags) error {
+	if len(command) == 0 {
+		return errors.New("没有设置 OGG 转换命令")
+	}
+	placeholders := strings.NewReplacer(
+		"{input}", input,
+		"{output}", output,
+		"{title}", tags.Title,
+		"{artist}", tags.Artist,
+		"{album}", tags.Album,
+	)
+	return execCommand(ctx, command, placeholders, "")
+}
+
+// PlaylistItem 播放列表中的一项，Seconds 为 -1 表示时长未知
+type PlaylistItem struct {
+	File    string
+	Title   string
+	Seconds int
+}
+
+// WriteM3U 写入扩展 M3U 播放列表
+func WriteM3U(w io.Writer, items []PlaylistItem) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "#EXTM3U")
+	for _, item := range items {
+		fmt.Fprintf(b, "#EXTINF:%d,%s\n%s\n", item.Seconds, item.Title, item.File)
+	}
+	return b.Flush()
+}
@@ -0,0 +1,76 @@
+package utils
+
+import "time"
+
+// Bookmark 书签
+type Bookmark struct {
+	Name         string    `json:"name"`
+	URL          string    `json:"url"` // 章节地址
+	ChapterTitle string    `json:"chapterTitle"`
+	Position     Position  `json:"position"`
+	Created      time.Time `json:"created"`
+}
+
+// Highlight 高亮的段落和笔记，Start 和 End 分别为第一行和最后一行的位置
+type Highlight struct {
+	URL          string    `json:"url"` // 章节地址
+	ChapterTitle string    `json:"chapterTitle"`
+	Start        Position  `json:"start"`
+	End          Position  `json:"end"`
+	Text         string    `json:"text"`
+	Note         string    `json:"note,omitempty"`
+	Created      time.Time `json:"created"`
+}
+
+// updateEntry 修改一本书的记录，书不在书架中时新建
+func (hm *HistoryManager) updateEntry(originURL string, update func(entry *HistoryEntry)) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	library, err := hm.load()
+	if err != nil {
+		return err
+	}
+
+	i := library.Find(originURL)
+	if i < 0 {
+		library.Books = append(library.Books, HistoryEntry{OriginURL: originURL, LastRead: time.Now()})
+		i = len(library.Books) - 1
+	}
+	update(&library.Books[i])
+	return hm.save(library)
+}
+
+// AddBookmark 添加书签
+func (hm *HistoryManager) AddBookmark(originURL string, bookmark Bookmark) error {
+	bookmark.Created = time.Now()
+	return hm.updateEntry(originURL, func(entry *HistoryEntry) {
+		entry.Bookmarks = append(entry.Bookmarks, bookmark)
+	})
+}
+
+// RemoveBookmark 删除第 index 个书签
+func (hm *HistoryManager) RemoveBookmark(originURL string, index int) error {
+	return hm.updateEntry(originURL, func(entry *HistoryEntry) {
+		if index >= 0 && index < len(entry.Bookmarks) {
+			entry.Bookmarks = append(entry.Bookmarks[:index], entry.Bookmarks[index+1:]...)
+		}
+	})
+}
+
+// AddHighlight 添加高亮
+func (hm *HistoryManager) AddHighlight(originURL string, highlight Highlight) error {
+	highlight.Created = time.Now()
+	return hm.updateEntry(originURL, func(entry *HistoryEntry) {
+		entry.Highlights = append(entry.Highlights, highlight)
+	})
+}
+
+// RemoveHighlight 删除第 index 个高亮
+func (hm *HistoryManager) RemoveHighlight(originURL string, index int) error {
+	return hm.updateEntry(originURL, func(entry *HistoryEntry) {
+		if index >= 0 && index < len(entry.Highlights) {
+			entry.Highlights = append(entry.Highlights[:index], entry.Highlights[index+1:]...)
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// KeyMapConfig 按键配置：在预设的基础上按操作名覆盖按键
+type KeyMapConfig struct {
+	Preset   string              `toml:"preset" json:"preset"`
+	Bindings map[string][]string `toml:"bindings" json:"bindings"`
+}
+
+// NetworkConfig 网络设置
+type NetworkConfig struct {
+	Timeout   int    `toml:"timeout"` // 请求超时，单位秒
+	Retries   int    `toml:"retries"` // 失败后的重试次数
+	Proxy     string `toml:"proxy"`   // 代理地址，为空时使用 HTTP_PROXY 等环境变量
+	UserAgent string `toml:"user_agent"`
+}
+
+// BossConfig 老板键设置
+type BossConfig struct {
+	Mode   string `toml:"mode"`   // 伪装内容：log、test、shell 或 file
+	File   string `toml:"file"`   // mode 为 file 时循环显示的文件
+	Status bool   `toml:"status"` // 阅读时把状态栏也显示为伪装内容
+}
+
+// TTSConfig 朗读设置
+type TTSConfig struct {
+	Command     []string `toml:"command"`      // 朗读命令，{text} 替换为文字，没有 {text} 时从标准输入传入
+	FileCommand []string `toml:"file_command"` // 合成 WAV 文件的命令，{file} 替换为输出文件
+	Output      string   `toml:"output"`       // 设置后不直接播放，把每章的朗读写入此目录下的 WAV 文件
+	OggCommand  []string `toml:"ogg_command"`  // 导出 OGG 时把 WAV 转换为 OGG 的命令
+}
+
+// RemoteConfig 远程控制设置
+type RemoteConfig struct {
+	Listen string `toml:"listen"` // HTTP 接口的监听地址，例如 127.0.0.1:7878，留空不启用
+	Token  string `toml:"token"`  // 设置后请求需带上 Authorization: Bearer <token>
+}
+
+// ServeConfig 网页阅读设置
+type ServeConfig struct {
+	Listen string `toml:"listen"` // 监听地址，默认允许局域网访问
+}
+
+// OPDSCatalog OPDS 书库，例如 Calibre 内容服务器的 http://localhost:8080/opds
+type OPDSCatalog struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+}
+
+// SourceConfig 书源的搜索接口。Results、Title、Author、Link 为 CSS 选择器，
+// Results 以 $ 开头时按 JSON 解析，各字段为相对每条结果的 JSONPath
+type SourceConfig struct {
+	Name     string `toml:"name"`
+	Search   string `toml:"search"`   // 搜索地址，{keyword} 替换为关键字
+	Encoding string `toml:"encoding"` // 关键字的编码，gbk 或留空表示 UTF-8
+	Results  string `toml:"results"`  // 每条搜索结果
+	Title    string `toml:"title"`    // 书名
+	Author   string `toml:"author"`   // 作者
+	Link     string `toml:"link"`     // 目录页链接，HTML 中取 href
+	LinkURL  string `toml:"link_url"` // 链接模板，{id} 替换为 link 取到的值
+	Timeout  int    `toml:"timeout"`  // 等待搜索结果的时间，单位秒，默认 10
+}
+
+// defaultTTS 默认使用系统自带或常见的语音合成命令
+func defaultTTS() TTSConfig {
+	tts := TTSConfig{
+		Command:     []string{"espeak-ng", "-v", "cmn", "{text}"},
+		FileCommand: []string{"espeak-ng", "-v", "cmn", "-w", "{file}", "{text}"},
+		OggCommand: []string{"ffmpeg", "-y", "-loglevel", "error", "-i", "{input}", "-c:a", "libvorbis",
+			"-metadata", "title={title}", "-metadata", "artist={artist}", "-metadata", "album={album}", "{output}"},
+	}
+	if runtime.GOOS == "darwin" {
+		tts.Command = []string{"say", "{text}"}
+		tts.FileCommand = []string{"say", "--file-format=WAVE", "--data-format=LEI16@22050", "-o", "{file}", "{text}"}
+	}
+	return tts
+}
+
+// Config 配置文件 $XDG_CONFIG_HOME/novel-reader/config.toml
+type Config struct {
+	Width     int            `toml:"width"`      // 每行最大显示宽度，0 表示跟随终端宽度
+	Lines     int            `toml:"lines"`      // 显示的行数
+	Theme     string         `toml:"theme"`      // 主题名称
+	Dim       bool           `toml:"dim"`        // 暗淡模式，降低文字亮度
+	Margin    int            `toml:"margin"`     // 正文左右两侧的空白列数
+	Padding   int            `toml:"padding"`    // 正文上下的空行数
+	Center    bool           `toml:"center"`     // 限制宽度时让正文居中
+	Prefetch  int            `toml:"prefetch"`   // 预读的章节数
+	Interval  int            `toml:"interval"`   // 自动翻页的间隔，单位秒
+	Rules     []string       `toml:"rules"`      // 内容过滤规则文件
+	Convert   string         `toml:"convert"`    // 新书默认的简繁转换：s2t、t2s、s2tw 或 t2hk
+	OpenCCDir string         `toml:"opencc_dir"` // OpenCC 词典目录，其中的词典优先于内置词典
+	Keymap    KeyMapConfig   `toml:"keymap"`
+	Network   NetworkConfig  `toml:"network"`
+	Boss      BossConfig     `toml:"boss"`
+	TTS       TTSConfig      `toml:"tts"`
+	Remote    RemoteConfig   `toml:"remote"`
+	Serve     ServeConfig    `toml:"serve"`
+	OPDS      []OPDSCatalog  `toml:"opds"`
+	Sources   []SourceConfig `toml:"sources"`
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() Config {
+	return Config{
+		Lines:    1,
+		Theme:    "default",
+		Prefetch: 1,
+		Interval: 5,
+		Network: NetworkConfig{
+			Timeout: 30,
+			Retries: 2,
+		},
+		Boss: BossConfig{
+			Mode: "log",
+		},
+		TTS: defaultTTS(),
+		Serve: ServeConfig{
+			Listen: ":8088",
+		},
+	}
+}
+
+// ConfigFile 返回配置文件路径
+func ConfigFile() string {
+	return filepath.Join(ConfigDir(), "config.toml")
+}
+
+// LoadConfig 读取配置文件，文件不存在时返回默认配置。
+// 相对路径的规则文件和伪装文件以配置目录为基准，朗读输出目录以数据目录为基准
+func LoadConfig() (Config, error) {
+	config := DefaultConfig()
+
+	file := ConfigFile()
+	if _, err := toml.DecodeFile(file, &config); err != nil && !os.IsNotExist(err) {
+		return config, fmt.Errorf("%s: %w", file, err)
+	}
+
+	for i, rule := range config.Rules {
+		if !filepath.IsAbs(rule) {
+			config.Rules[i] = filepath.Join(ConfigDir(), rule)
+		}
+	}
+
+	if config.TTS.Output != "" && !filepath.IsAbs(config.TTS.Output) {
+		config.TTS.Output = filepath.Join(DataDir(), config.TTS.Output)
+	}
+	if config.Boss.File != "" && !filepath.IsAbs(config.Boss.File) {
+		config.Boss.File = filepath.Join(ConfigDir(), config.Boss.File)
+	}
+
+	// 兼容旧版本单独的 keymap.json
+	if config.Keymap.Preset == "" && config.Keymap.Bindings == nil {
+		legacy := filepath.Join(ConfigDir(), "keymap.json")
+		if data, err := os.ReadFile(legacy); err == nil {
+			if err := json.Unmarshal(data, &config.Keymap); err != nil {
+				return config, fmt.Errorf("%s: %w", legacy, err)
+			}
+		}
+	}
+	return config, nil
+}
+
+// topLevelKey 匹配配置文件中顶层的键
+var topLevelKey = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=`)
+
+// UpdateConfig 修改配置文件中顶层的设置，保留其他内容和注释。
+// 值只支持字符串、整数和布尔值
+func UpdateConfig(values map[string]interface{}) error {
+	file := ConfigFile()
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	// 顶层设置在第一个表之前
+	end := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			end = i
+			break
+		}
+	}
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	done := map[string]bool{}
+	for i := 0; i < end; i++ {
+		match := topLevelKey.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		if value, ok := values[match[1]]; ok {
+			comment := inlineComment(lines[i][len(match[0]):])
+			lines[i] = fmt.Sprintf("%s = %s%s", match[1], tomlValue(value), comment)
+			done[match[1]] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !done[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	added := make([]string, 0, len(keys))
+	for _, key := range keys {
+		added = append(added, fmt.Sprintf("%s = %s", key, tomlValue(values[key])))
+	}
+	lines = append(lines[:end], append(added, lines[end:]...)...)
+
+	// 确认修改后的文件仍然有效
+	content := strings.Join(lines, "\n") + "\n"
+	var config Config
+	if _, err := toml.Decode(content, &config); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(content), 0644)
+}
+
+// inlineComment 返回值后面的注释（包括前面的空白），value 为等号后的内容。
+// 字符串中的 # 不算注释
+func inlineComment(value string) string {
+	rest := strings.TrimLeft(value, " \t")
+	switch {
+	case strings.HasPrefix(rest, `"`):
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		rest = rest[min(end+1, len(rest)):]
+	case strings.HasPrefix(rest, "'"):
+		if end := strings.Index(rest[1:], "'"); end >= 0 {
+			rest = rest[end+2:]
+		}
+	}
+	if i := strings.Index(rest, "#"); i >= 0 {
+		// 保留值与注释之间原来的空白
+		space := rest[len(strings.TrimRight(rest[:i], " \t")):i]
+		if space == "" {
+			space = " "
+		}
+		return space + rest[i:]
+	}
+	return ""
+}
+
+// tomlValue 把值写成 TOML 格式
+func tomlValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	setupHome(t)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Lines != 1 || config.Network.Timeout != 30 {
+		t.Errorf("配置文件不存在时应使用默认配置，实际: %+v", config)
+	}
+
+	content := `
+lines = 3
+rules = ["ads.txt"]
+
+[keymap]
+preset = "less"
+
+[network]
+proxy = "http://127.0.0.1:7890"
+`
+	if err := os.WriteFile(ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Lines != 3 || config.Keymap.Preset != "less" || config.Network.Proxy != "http://127.0.0.1:7890" {
+		t.Errorf("配置读取有误: %+v", config)
+	}
+	if config.Network.Timeout != 30 || config.Prefetch != 1 {
+		t.Errorf("未设置的项应保留默认值: %+v", config)
+	}
+	if config.Rules[0] != filepath.Join(ConfigDir(), "ads.txt") {
+		t.Errorf("规则文件应以配置目录为基准: %s", config.Rules[0])
+	}
+}
+
+func TestMigrateLegacyDir(t *testing.T) {
+	home := setupHome(t)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+
+	legacy := filepath.Join(home, ".nvrd")
+	if err := os.MkdirAll(filepath.Join(legacy, "cache", "book"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"history.json":            `{"books":[]}`,
+		"keymap.json":             `{"preset":"emacs"}`,
+		"cache/book/chapter.json": `{}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(legacy, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := MigrateLegacyDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{
+		filepath.Join(home, "data", "novel-reader", "history.json"),
+		filepath.Join(home, ".config", "novel-reader", "keymap.json"),
+		filepath.Join(home, ".cache", "novel-reader", "chapters", "book", "chapter.json"),
+	} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("文件未迁移: %v", err)
+		}
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("迁移后应删除 ~/.nvrd")
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Keymap.Preset != "emacs" {
+		t.Errorf("应读取旧的 keymap.json，实际: %+v", config.Keymap)
+	}
+}
+
+func TestRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.txt")
+	content := "# 去掉广告\n请收藏本站.*\n\n笔趣阁 => 某站\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := rules.Apply("正文\n请收藏本站：www.example.com\n来自笔趣阁")
+	if expected := "正文\n\n来自某站"; actual != expected {
+		t.Errorf("期望 %q，实际 %q", expected, actual)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	setupHome(t)
+
+	content := "# 阅读设置\ntheme = \"dark\"  # 主题\nconvert = \"#s2t\" # 简繁\nlines = 3\n\n[network]\nretries = 1\n"
+	if err := os.WriteFile(ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateConfig(map[string]interface{}{"theme": "sepia", "dim": true, "convert": "t2s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(ConfigFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# 阅读设置\ntheme = \"sepia\"  # 主题\nconvert = \"t2s\" # 简繁\nlines = 3\ndim = true\n\n[network]\nretries = 1\n"
+	if string(data) != expected {
+		t.Errorf("期望:\n%s\n实际:\n%s", expected, data)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Theme != "sepia" || !config.Dim || config.Network.Retries != 1 {
+		t.Errorf("修改后的配置读取有误: %+v", config)
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// appName 配置、数据和缓存目录的名称
+const appName = "novel-reader"
+
+// xdgDir 返回 XDG 目录，环境变量未设置时使用 $HOME 下的默认位置，不存在时创建
+func xdgDir(env string, fallback ...string) string {
+	base := os.Getenv(env)
+	if base == "" || !filepath.IsAbs(base) {
+		base = filepath.Join(append([]string{os.Getenv("HOME")}, fallback...)...)
+	}
+	dir := filepath.Join(base, appName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+	return dir
+}
+
+// ConfigDir 返回配置目录，$XDG_CONFIG_HOME/novel-reader
+func ConfigDir() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir 返回数据目录，$XDG_DATA_HOME/novel-reader
+func DataDir() string {
+	return xdgDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// CacheDir 返回缓存目录，$XDG_CACHE_HOME/novel-reader
+func CacheDir() string {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// legacyDir 旧版本使用的数据目录
+func legacyDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".nvrd")
+}
+
+// MigrateLegacyDir 将旧版本 ~/.nvrd 中的数据迁移到 XDG 目录，
+// 目标位置已存在的文件不会被覆盖，迁移完成后删除空的 ~/.nvrd
+func MigrateLegacyDir() error {
+	legacy := legacyDir()
+	if _, err := os.Stat(legacy); os.IsNotExist(err) {
+		return nil
+	}
+
+	moves := []struct {
+		from string
+		to   string
+	}{
+		{filepath.Join(legacy, "history.json"), filepath.Join(DataDir(), "history.json")},
+		{filepath.Join(legacy, "keymap.json"), filepath.Join(ConfigDir(), "keymap.json")},
+		{filepath.Join(legacy, "cache"), filepath.Join(CacheDir(), "chapters")},
+	}
+	for _, move := range moves {
+		if _, err := os.Stat(move.from); os.IsNotExist(err) {
+			continue
+		}
+		if _, err := os.Stat(move.to); err == nil {
+			continue
+		}
+		if err := moveAll(move.from, move.to); err != nil {
+			return err
+		}
+	}
+
+	// 只删除空目录，留下无法识别的文件
+	os.Remove(legacy)
+	return nil
+}
+
+// moveAll 移动文件或目录，跨文件系统时复制后删除
+func moveAll(from string, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	err := filepath.Walk(from, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(file, target)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+func copyFile(from string, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
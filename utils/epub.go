@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// EPUBChapter EPUB 中的一章
+type EPUBChapter struct {
+	Title      string
+	Paragraphs []string
+}
+
+// EPUBBook 生成 EPUB 所需的书籍信息
+type EPUBBook struct {
+	ID       string // 唯一标识，例如 urn:uuid 或书的地址
+	Title    string
+	Author   string
+	Language string
+	Modified time.Time
+	Chapters []EPUBChapter
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// chapterFile 第 i 章的文件名
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter%04d.xhtml", i+1)
+}
+
+// WriteEPUB 生成 EPUB 3 文件，同时包含 toc.ncx 以兼容只支持 EPUB 2 的阅读器
+func WriteEPUB(w io.Writer, book EPUBBook) error {
+	if book.Language == "" {
+		book.Language = "zh"
+	}
+	if book.Modified.IsZero() {
+		book.Modified = time.Now()
+	}
+
+	z := zip.NewWriter(w)
+	// mimetype 必须是第一个文件且不压缩
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	io.WriteString(f, "application/epub+zip")
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", book.opf()},
+		{"OEBPS/nav.xhtml", book.nav()},
+		{"OEBPS/toc.ncx", book.ncx()},
+	}
+	for i, chapter := range book.Chapters {
+		files = append(files, struct{ name, content string }{"OEBPS/" + chapterFile(i), chapter.xhtml(book.Language)})
+	}
+	for _, file := range files {
+		f, err := z.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// esc 转义 XML 文本
+func esc(s string) string {
+	return html.EscapeString(s)
+}
+
+func (book EPUBBook) opf() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="%s">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:creator>%s</dc:creator>
+    <dc:language>%s</dc:language>
+    <meta property="dcterms:modified">%s</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+`, esc(book.Language), esc(book.ID), esc(book.Title), esc(book.Author), esc(book.Language), book.Modified.UTC().Format("2006-01-02T15:04:05Z"))
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, "    <item id=\"c%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterFile(i))
+	}
+	b.WriteString("  </manifest>\n  <spine toc=\"ncx\">\n")
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, "    <itemref idref=\"c%d\"/>\n", i+1)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+func (book EPUBBook) nav() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s">
+<head><title>%s</title></head>
+<body>
+  <nav epub:type="toc">
+    <h1>目录</h1>
+    <ol>
+`, esc(book.Language), esc(book.Title))
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&b, "      <li><a href=\"%s\">%s</a></li>\n", chapterFile(i), esc(chapter.Title))
+	}
+	b.WriteString("    </ol>\n  </nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func (book EPUBBook) ncx() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head><meta name="dtb:uid" content="%s"/></head>
+  <docTitle><text>%s</text></docTitle>
+  <navMap>
+`, esc(book.ID), esc(book.Title))
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&b, "    <navPoint id=\"p%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, esc(chapter.Title), chapterFile(i))
+	}
+	b.WriteString("  </navMap>\n</ncx>\n")
+	return b.String()
+}
+
+func (chapter EPUBChapter) xhtml(lang string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="%s">
+<head><title>%s</title></head>
+<body>
+  <h2>%s</h2>
+`, esc(lang), esc(chapter.Title), esc(chapter.Title))
+	for _, p := range chapter.Paragraphs {
+		fmt.Fprintf(&b, "  <p>%s</p>\n", esc(strings.TrimSpace(p)))
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -4,51 +4,154 @@ import (
 	"encoding/json"
 	"os"
 	"path"
+	"sort"
+	"sync"
+	"time"
 )
 
 type HistoryManager struct {
 	filePath string
+	mu       sync.Mutex
 }
 
-// 视图函数
+// HistoryEntry 一本书的阅读记录
 type HistoryEntry struct {
-	OriginURL string `json:"originUrl"`
-	LastURL   string `json:"lastUrl"`
-	Cursor    int    `json:"cursor"`
+	OriginURL    string    `json:"originUrl"` // 打开时的地址或文件路径，作为书的标识
+	LastURL      string    `json:"lastUrl"`   // 当前章节地址
+	Cursor       int       `json:"cursor"`    // 折行后的行号，仅用于兼容旧的历史记录
+	Position     Position  `json:"position"`
+	Source       string    `json:"source,omitempty"`  // JSON 接口配置文件
+	Convert      string    `json:"convert,omitempty"` // 简繁转换
+	Title        string    `json:"title"`             // 书名
+	ChapterTitle string    `json:"chapterTitle"`
+	Progress     float64   `json:"progress"` // 当前章节的阅读进度，0-100
+	LastRead     time.Time `json:"lastRead"`
+
+	ChapterIndex int `json:"chapterIndex"`           // 当前章节在目录中的位置，从 0 开始
+	ChapterTotal int `json:"chapterTotal,omitempty"` // 目录中的章节数，0 表示未知
+	ChapterChars int `json:"chapterChars,omitempty"` // 当前章节的字数，用于估算剩余时间
+
+	// 阅读统计只通过 AddReading 修改
+	Stats ReadingStats `json:"stats"`
+
+	// 书签和高亮只通过 AddBookmark、AddHighlight 等方法修改
+	Bookmarks  []Bookmark  `json:"bookmarks,omitempty"`
+	Highlights []Highlight `json:"highlights,omitempty"`
 }
 
-func NewHistoryManager() *HistoryManager {
-	historyDir := path.Join(os.Getenv("HOME"), ".nvrd")
-	historyFile := path.Join(historyDir, "history.json")
+// Library 书架，按最近阅读时间排序
+type Library struct {
+	Books []HistoryEntry `json:"books"`
+}
 
-	// 确保目录存在
-	if _, err := os.Stat(historyDir); os.IsNotExist(err) {
-		os.MkdirAll(historyDir, 0755)
+// Find 返回书在书架中的下标，不存在时返回 -1
+func (l *Library) Find(originURL string) int {
+	for i, book := range l.Books {
+		if book.OriginURL == originURL {
+			return i
+		}
 	}
+	return -1
+}
+
+func NewHistoryManager() *HistoryManager {
+	historyFile := path.Join(DataDir(), "history.json")
 
 	return &HistoryManager{
 		filePath: historyFile,
 	}
 }
 
-func (hm *HistoryManager) Load() (HistoryEntry, error) {
-	var history HistoryEntry
+// Load 读取书架
+func (hm *HistoryManager) Load() (Library, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.load()
+}
+
+func (hm *HistoryManager) load() (Library, error) {
+	var library Library
 
 	if data, err := os.ReadFile(hm.filePath); err == nil {
-		err = json.Unmarshal(data, &history)
+		err = json.Unmarshal(data, &library)
 		if err != nil {
-			return HistoryEntry{}, err
+			return Library{}, err
+		}
+		if library.Books == nil {
+			// 兼容旧版本只保存一条记录的格式
+			var entry HistoryEntry
+			if json.Unmarshal(data, &entry) == nil && entry.OriginURL != "" {
+				library.Books = []HistoryEntry{entry}
+			}
 		}
 	}
 
-	return history, nil
+	return library, nil
 }
 
-func (hm *HistoryManager) Save(history HistoryEntry) error {
-	data, err := json.Marshal(history)
+func (hm *HistoryManager) save(library Library) error {
+	sort.SliceStable(library.Books, func(i, j int) bool {
+		return library.Books[i].LastRead.After(library.Books[j].LastRead)
+	})
+
+	data, err := json.Marshal(library)
 	if err != nil {
 		return err
 	}
 
 	return os.WriteFile(hm.filePath, data, 0644)
 }
+
+// Get 返回一本书的阅读记录
+func (hm *HistoryManager) Get(originURL string) (HistoryEntry, bool) {
+	library, err := hm.Load()
+	if err != nil {
+		return HistoryEntry{}, false
+	}
+	if i := library.Find(originURL); i >= 0 {
+		return library.Books[i], true
+	}
+	return HistoryEntry{}, false
+}
+
+// Save 保存一本书的阅读记录并更新最近阅读时间
+func (hm *HistoryManager) Save(history HistoryEntry) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	library, err := hm.load()
+	if err != nil {
+		return err
+	}
+
+	history.LastRead = time.Now()
+	if i := library.Find(history.OriginURL); i >= 0 {
+		// 有的章节页解析不到书名，保留之前的书名
+		if history.Title == "" {
+			history.Title = library.Books[i].Title
+		}
+		history.Bookmarks = library.Books[i].Bookmarks
+		history.Highlights = library.Books[i].Highlights
+		history.Stats = library.Books[i].Stats
+		library.Books[i] = history
+	} else {
+		library.Books = append(library.Books, history)
+	}
+	return hm.save(library)
+}
+
+// Remove 从书架中删除一本书
+func (hm *HistoryManager) Remove(originURL string) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	library, err := hm.load()
+	if err != nil {
+		return err
+	}
+
+	if i := library.Find(originURL); i >= 0 {
+		library.Books = append(library.Books[:i], library.Books[i+1:]...)
+	}
+	return hm.save(library)
+}
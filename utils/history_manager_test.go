@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+// setupHome 使用临时的 HOME，并清除 XDG 环境变量
+func setupHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	return home
+}
+
+func TestHistoryManagerLibrary(t *testing.T) {
+	setupHome(t)
+	hm := NewHistoryManager()
+
+	if err := hm.Save(HistoryEntry{OriginURL: "a.txt", LastURL: "a.txt", Title: "甲"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := hm.Save(HistoryEntry{OriginURL: "https://example.com/b/1.html", LastURL: "https://example.com/b/2.html", Title: "乙"}); err != nil {
+		t.Fatal(err)
+	}
+	// 再次打开第一本书，书名解析不到时保留原来的书名
+	if err := hm.Save(HistoryEntry{OriginURL: "a.txt", LastURL: "a.txt", Cursor: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	library, err := hm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(library.Books) != 2 {
+		t.Fatalf("书架应有两本书，实际: %+v", library.Books)
+	}
+	if library.Books[0].OriginURL != "a.txt" || library.Books[0].Title != "甲" || library.Books[0].Cursor != 10 {
+		t.Errorf("最近阅读的书应排在最前面，实际: %+v", library.Books[0])
+	}
+
+	if err := hm.Remove("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hm.Get("a.txt"); ok {
+		t.Error("删除后不应再找到这本书")
+	}
+	if entry, ok := hm.Get("https://example.com/b/1.html"); !ok || entry.LastURL != "https://example.com/b/2.html" {
+		t.Errorf("应找到第二本书，实际: %+v", entry)
+	}
+}
+
+func TestHistoryManagerLegacyFormat(t *testing.T) {
+	setupHome(t)
+	hm := NewHistoryManager()
+
+	legacy := `{"originUrl":"https://example.com/1.html","lastUrl":"https://example.com/5.html","cursor":3}`
+	if err := os.WriteFile(path.Join(DataDir(), "history.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := hm.Get("https://example.com/1.html")
+	if !ok || entry.LastURL != "https://example.com/5.html" || entry.Cursor != 3 {
+		t.Errorf("应兼容旧的历史记录格式，实际: %+v", entry)
+	}
+}
+
+func TestHistoryManagerKeepsBookmarks(t *testing.T) {
+	setupHome(t)
+	hm := NewHistoryManager()
+
+	if err := hm.AddBookmark("a.txt", Bookmark{Name: "开头", URL: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := hm.AddHighlight("a.txt", Highlight{URL: "a.txt", Text: "一段话", Note: "笔记"}); err != nil {
+		t.Fatal(err)
+	}
+	// 保存阅读位置不应覆盖书签和高亮
+	if err := hm.Save(HistoryEntry{OriginURL: "a.txt", LastURL: "a.txt", Cursor: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, _ := hm.Get("a.txt")
+	if len(entry.Bookmarks) != 1 || len(entry.Highlights) != 1 || entry.Highlights[0].Note != "笔记" {
+		t.Fatalf("书签和高亮丢失: %+v", entry)
+	}
+
+	if err := hm.RemoveBookmark("a.txt", 0); err != nil {
+		t.Fatal(err)
+	}
+	entry, _ = hm.Get("a.txt")
+	if len(entry.Bookmarks) != 0 || len(entry.Highlights) != 1 {
+		t.Errorf("应只删除书签: %+v", entry)
+	}
+}
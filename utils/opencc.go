@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// openccDicts 内置的 OpenCC 词典，来源和许可证见 opencc/README.md。
+// opencc_dir 中有同名词典时优先使用
+//
+//go:embed opencc/*.txt
+var openccDicts embed.FS
+
+// conversions 各转换依次使用的词典组，与 OpenCC 的同名配置对应
+var conversions = map[string][][]string{
+	"s2t":  {{"STPhrases", "STCharacters"}},
+	"t2s":  {{"TSPhrases", "TSCharacters"}},
+	"s2tw": {{"STPhrases", "STCharacters"}, {"TWVariants"}},
+	"t2hk": {{"HKVariants"}},
+}
+
+// Conversions 支持的简繁转换
+var Conversions = []string{"s2t", "t2s", "s2tw", "t2hk"}
+
+// dict 一组词典合并后的词条，转换时按最长匹配
+type dict struct {
+	words  map[string]string
+	maxLen int // 最长词条的字数
+}
+
+// Converter 简繁转换
+type Converter struct {
+	Name   string
+	stages []*dict
+}
+
+// NewConverter 创建简繁转换，dir 中有同名的 OpenCC 词典文件时优先使用
+func NewConverter(name string, dir string) (*Converter, error) {
+	groups, ok := conversions[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的简繁转换: %s，可选: %s", name, strings.Join(Conversions, "、"))
+	}
+
+	c := &Converter{Name: name}
+	for _, group := range groups {
+		d := &dict{words: map[string]string{}}
+		for _, file := range group {
+			if err := d.load(file, dir); err != nil {
+				return nil, err
+			}
+		}
+		c.stages = append(c.stages, d)
+	}
+	return c, nil
+}
+
+// load 读取词典，先读到的词条优先
+func (d *dict) load(name string, dir string) error {
+	var r io.ReadCloser
+	if dir != "" {
+		if f, err := os.Open(filepath.Join(dir, name+".txt")); err == nil {
+			r = f
+		}
+	}
+	if r == nil {
+		f, err := openccDicts.Open("opencc/" + name + ".txt")
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, values, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(values)
+		if !ok || key == "" || len(fields) == 0 {
+			continue
+		}
+		if _, exists := d.words[key]; !exists {
+			d.words[key] = fields[0]
+			d.maxLen = max(d.maxLen, len([]rune(key)))
+		}
+	}
+	return scanner.Err()
+}
+
+// convert 从左到右按最长匹配替换
+func (d *dict) convert(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(runes); {
+		matched := false
+		for n := min(d.maxLen, len(runes)-i); n > 0; n-- {
+			if word, ok := d.words[string(runes[i:i+n])]; ok {
+				b.WriteString(word)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteRune(runes[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// Convert 转换文字，c 为 nil 时原样返回
+func (c *Converter) Convert(s string) string {
+	if c == nil {
+		return s
+	}
+	for _, stage := range c.stages {
+		s = stage.convert(s)
+	}
+	return s
+}
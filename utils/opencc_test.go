@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConverter(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"s2t", "他说：“这里的头发干净了，然后我们去吃面条。”", "他說：“這裏的頭髮乾淨了，然後我們去喫麪條。”"},
+		{"s2tw", "这里的人为什么", "這裡的人為什麼"},
+		{"t2s", "這裡的頭髮乾淨了，後來他們說乾隆", "这里的头发干净了，后来他们说乾隆"},
+		{"t2hk", "他說爲了脫身", "他説為了脱身"},
+	}
+	for _, c := range cases {
+		converter, err := NewConverter(c.name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := converter.Convert(c.input); actual != c.expected {
+			t.Errorf("%s: 期望 %s，实际 %s", c.name, c.expected, actual)
+		}
+	}
+
+	// 一对多的字按词组区分
+	phrases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"s2t", "一碗面", "一碗麪"},
+		{"s2tw", "一碗面", "一碗麵"},
+		{"s2t", "面对", "面對"},
+		{"s2t", "几乎", "幾乎"},
+		{"s2t", "茶几", "茶几"},
+		{"s2t", "小丑", "小丑"},
+		{"s2t", "丑时", "丑時"},
+		{"t2s", "幾乎", "几乎"},
+	}
+	for _, c := range phrases {
+		converter, err := NewConverter(c.name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := converter.Convert(c.input); actual != c.expected {
+			t.Errorf("%s: 期望 %s，实际 %s", c.name, c.expected, actual)
+		}
+	}
+
+	var none *Converter
+	if none.Convert("这里") != "这里" {
+		t.Error("nil 时应原样返回")
+	}
+	if _, err := NewConverter("s2hk", ""); err == nil {
+		t.Error("未知的转换应返回错误")
+	}
+}
+
+func TestConverterDictDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "STCharacters.txt"), []byte("说\t説 說\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	converter, err := NewConverter("s2t", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := converter.Convert("说头发"); actual != "説頭髮" {
+		t.Errorf("应使用目录中的词典，实际 %s", actual)
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fingerprintLength 位置指纹的字符数
+const fingerprintLength = 16
+
+// Position 与折行宽度无关的阅读位置
+type Position struct {
+	Paragraph   int    `json:"paragraph"`   // 段落序号
+	Offset      int    `json:"offset"`      // 段落内的字符（rune）偏移
+	Fingerprint string `json:"fingerprint"` // 位置处的一小段文字，用于内容变化后重新定位
+}
+
+// PositionAt 返回第 cursor 行的阅读位置
+func PositionAt(paragraphs []string, lines []Line, cursor int) Position {
+	if cursor < 0 || cursor >= len(lines) {
+		return Position{}
+	}
+	return NewPosition(paragraphs, lines[cursor].Paragraph, lines[cursor].Offset)
+}
+
+// NewPosition 返回段落偏移处的阅读位置
+func NewPosition(paragraphs []string, paragraph int, offset int) Position {
+	pos := Position{Paragraph: paragraph, Offset: offset}
+	if paragraph >= 0 && paragraph < len(paragraphs) {
+		pos.Fingerprint = fingerprint([]rune(paragraphs[paragraph]), offset)
+	}
+	return pos
+}
+
+// Locate 返回阅读位置在重新折行后的行号。
+// 优先使用段落和偏移，指纹对不上时（例如站点重新排版）在全文中查找离原段落最近的指纹
+func Locate(paragraphs []string, lines []Line, pos Position) int {
+	if pos.Fingerprint == "" {
+		return FindLine(lines, pos.Paragraph, pos.Offset)
+	}
+
+	if pos.Paragraph < len(paragraphs) {
+		runes := []rune(paragraphs[pos.Paragraph])
+		if pos.Offset <= len(runes) && fingerprint(runes, pos.Offset) == pos.Fingerprint {
+			return FindLine(lines, pos.Paragraph, pos.Offset)
+		}
+	}
+
+	best, bestDistance := -1, 0
+	bestOffset := 0
+	for i, paragraph := range paragraphs {
+		text := compact(paragraph)
+		index := strings.Index(text, pos.Fingerprint)
+		if index < 0 {
+			continue
+		}
+		distance := i - pos.Paragraph
+		if distance < 0 {
+			distance = -distance
+		}
+		if best < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+			bestOffset = runeOffset(paragraph, len([]rune(text[:index])))
+		}
+	}
+	if best >= 0 {
+		return FindLine(lines, best, bestOffset)
+	}
+	return FindLine(lines, pos.Paragraph, pos.Offset)
+}
+
+// fingerprint 从 offset 开始取去掉空白后的若干字符
+func fingerprint(runes []rune, offset int) string {
+	if offset > len(runes) {
+		return ""
+	}
+	text := []rune(compact(string(runes[offset:])))
+	if len(text) > fingerprintLength {
+		text = text[:fingerprintLength]
+	}
+	return string(text)
+}
+
+// compact 去掉所有空白字符
+func compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// runeOffset 将去掉空白后的第 n 个字符换算为原段落中的 rune 偏移
+func runeOffset(paragraph string, n int) int {
+	count := 0
+	for i, r := range []rune(paragraph) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if count == n {
+			return i
+		}
+		count++
+	}
+	return len([]rune(paragraph))
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestLocate(t *testing.T) {
+	text := "    第一段第一段第一段\n    第二段甲乙丙丁戊己庚辛壬癸\n    第三段"
+	paragraphs := SplitParagraphs(text)
+	lines := WordWrap(text, 12)
+	cursor := 3
+	pos := PositionAt(paragraphs, lines, cursor)
+
+	// 宽度变化
+	wide := WordWrap(text, 80)
+	if line := Locate(paragraphs, wide, pos); line != 1 {
+		t.Errorf("宽度变化后应定位到第二段，实际: %d", line)
+	}
+
+	// 站点重新排版：前面插入了一段并去掉了缩进
+	changed := "广告\n第一段第一段第一段\n第二段甲乙丙丁戊己庚辛壬癸\n第三段"
+	changedParagraphs := SplitParagraphs(changed)
+	changedLines := WordWrap(changed, 12)
+	line := Locate(changedParagraphs, changedLines, pos)
+	if changedLines[line].Paragraph != 2 || changedLines[line].Offset > lines[cursor].Offset {
+		t.Errorf("重新排版后应定位到第二段，实际: %+v", changedLines[line])
+	}
+}
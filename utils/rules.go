@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// rule 一条过滤规则
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Rules 内容过滤规则，用于去掉广告、站点水印等
+//
+// 规则文件每行一条正则表达式，匹配的文字会被删除；
+// 写成“正则 => 替换”时替换为指定文字。空行和 # 开头的行会被忽略
+type Rules struct {
+	rules []rule
+}
+
+// LoadRules 读取规则文件
+func LoadRules(files []string) (*Rules, error) {
+	rules := &Rules{}
+	for _, file := range files {
+		if err := rules.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rules) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		pattern, replacement := text, ""
+		if i := strings.Index(text, " => "); i >= 0 {
+			pattern, replacement = strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+4:])
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		r.rules = append(r.rules, rule{pattern: re, replacement: replacement})
+	}
+	return scanner.Err()
+}
+
+// Apply 对内容应用所有规则
+func (r *Rules) Apply(content string) string {
+	if r == nil {
+		return content
+	}
+	for _, rule := range r.rules {
+		content = rule.pattern.ReplaceAllString(content, rule.replacement)
+	}
+	return content
+}
@@ -0,0 +1,63 @@
+package utils
+
+import "unicode"
+
+// Match 关键字在内容中的位置
+type Match struct {
+	Paragraph int
+	Offset    int // 段落内的字符（rune）偏移
+	Length    int
+}
+
+// FindMatches 在段落中查找关键字，忽略大小写
+func FindMatches(paragraphs []string, query string) []Match {
+	pattern := lowerRunes(query)
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i, paragraph := range paragraphs {
+		text := lowerRunes(paragraph)
+		for offset := 0; offset+len(pattern) <= len(text); offset++ {
+			if equalRunes(text[offset:offset+len(pattern)], pattern) {
+				matches = append(matches, Match{Paragraph: i, Offset: offset, Length: len(pattern)})
+				offset += len(pattern) - 1
+			}
+		}
+	}
+	return matches
+}
+
+// MatchContext 返回匹配位置前后 radius 个字符的上下文
+func MatchContext(paragraph string, match Match, radius int) string {
+	runes := []rune(paragraph)
+	start := max(match.Offset-radius, 0)
+	end := min(match.Offset+match.Length+radius, len(runes))
+	context := string(runes[start:end])
+	if start > 0 {
+		context = "…" + context
+	}
+	if end < len(runes) {
+		context += "…"
+	}
+	return context
+}
+
+// lowerRunes 转为小写，逐字符转换以保持偏移不变
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+func equalRunes(a []rune, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
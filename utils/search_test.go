@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	paragraphs := []string{"    Hello 世界，hello", "没有", "世界之大"}
+
+	matches := FindMatches(paragraphs, "hello")
+	expected := []Match{{Paragraph: 0, Offset: 4, Length: 5}, {Paragraph: 0, Offset: 13, Length: 5}}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("期望: %+v\n实际: %+v", expected, matches)
+	}
+
+	matches = FindMatches(paragraphs, "世界")
+	if len(matches) != 2 || matches[1].Paragraph != 2 || matches[1].Offset != 0 {
+		t.Errorf("中文匹配结果不符: %+v", matches)
+	}
+
+	if context := MatchContext(paragraphs[0], matches[0], 2); context != "…o 世界，h…" {
+		t.Errorf("上下文不符: %q", context)
+	}
+}
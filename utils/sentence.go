@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sentence 朗读时的一句话，Paragraph 和 Offset 为第一个字的位置
+type Sentence struct {
+	Paragraph int
+	Offset    int
+	Text      string
+}
+
+// isSentenceEnd 句末标点
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune("。！？!?；;…", r)
+}
+
+// isCloser 句末标点后的引号、括号，归入前一句
+func isCloser(r rune) bool {
+	return strings.ContainsRune("”’」』）)》\"'", r)
+}
+
+// SplitSentences 按句末标点把段落分成句子，跳过空白
+func SplitSentences(paragraphs []string) []Sentence {
+	var sentences []Sentence
+	for p, paragraph := range paragraphs {
+		runes := []rune(paragraph)
+		start := 0
+		add := func(end int) {
+			for start < end && unicode.IsSpace(runes[start]) {
+				start++
+			}
+			if text := strings.TrimSpace(string(runes[start:end])); text != "" {
+				sentences = append(sentences, Sentence{Paragraph: p, Offset: start, Text: text})
+			}
+			start = end
+		}
+
+		for i := 0; i < len(runes); i++ {
+			if !isSentenceEnd(runes[i]) {
+				continue
+			}
+			end := i + 1
+			for end < len(runes) && (isSentenceEnd(runes[end]) || isCloser(runes[end])) {
+				end++
+			}
+			add(end)
+			i = end - 1
+		}
+		add(len(runes))
+	}
+	return sentences
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Speaker 朗读一段文字，读完或 ctx 取消后返回
+type Speaker interface {
+	Speak(ctx context.Context, text string) error
+}
+
+// ChapterSpeaker 按章节保存朗读结果的 Speaker，开始朗读一章时调用 StartChapter，
+// index 为章节在目录中的序号，从 1 开始
+type ChapterSpeaker interface {
+	Speaker
+	StartChapter(index int, title string) error
+}
+
+// runCommand 执行朗读命令，{file} 替换为输出文件，{text} 替换为文字，
+// 参数中没有 {text} 时从标准输入传入文字
+func runCommand(ctx context.Context, command []string, text string, file string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("没有设置朗读命令")
+	}
+	hasText := false
+	for _, arg := range command {
+		if strings.Contains(arg, "{text}") {
+			hasText = true
+		}
+	}
+	stdin := ""
+	if !hasText {
+		stdin = text
+	}
+	return execCommand(ctx, command, strings.NewReplacer("{file}", file, "{text}", text), stdin)
+}
+
+// execCommand 用 placeholders 替换参数中的占位符后执行命令，出错时带上标准错误输出
+func execCommand(ctx context.Context, command []string, placeholders *strings.Replacer, stdin string) error {
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = placeholders.Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+// CommandSpeaker 调用本地命令朗读，例如 espeak-ng、piper 或 macOS 的 say
+type CommandSpeaker struct {
+	Command []string // {text} 替换为要朗读的文字
+}
+
+// Speak 实现 Speaker
+func (s *CommandSpeaker) Speak(ctx context.Context, text string) error {
+	return runCommand(ctx, s.Command, text, "")
+}
+
+// FileSpeaker 不直接播放，把每章朗读的音频写入目录下的 WAV 文件
+type FileSpeaker struct {
+	Command []string // 合成 WAV 的命令，{file} 替换为输出文件，{text} 替换为文字
+	Dir     string
+
+	mu      sync.Mutex
+	writer  *WAVWriter
+	title   string
+	chapter int
+}
+
+// unsafeFileChars 文件名中不能使用的字符
+var unsafeFileChars = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// ChapterFileName 章节音频的文件名，以序号开头以便排序
+func ChapterFileName(index int, title string, ext string) string {
+	return fmt.Sprintf("%04d_%s%s", index, SafeFileName(title, "chapter"), ext)
+}
+
+// SafeFileName 把标题转换为可用的文件名，为空时使用 fallback
+func SafeFileName(title string, fallback string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(title, "_"), "_.")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// StartChapter 实现 ChapterSpeaker，之后的朗读写入这一章的文件，
+// 同一章重新开始朗读时接在原来的文件后面
+func (s *FileSpeaker) StartChapter(index int, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index == s.chapter && title == s.title {
+		return nil
+	}
+	if s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+	}
+	s.chapter = max(index, 1)
+	s.title = title
+	return os.MkdirAll(s.Dir, 0755)
+}
+
+// Speak 实现 Speaker，合成一句话并追加到当前章节的文件中
+func (s *FileSpeaker) Speak(ctx context.Context, text string) error {
+	format, data, err := SynthesizeWAV(ctx, s.Command, text)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer == nil {
+		if s.chapter == 0 {
+			s.chapter = 1
+		}
+		if err := os.MkdirAll(s.Dir, 0755); err != nil {
+			return err
+		}
+		path := filepath.Join(s.Dir, ChapterFileName(s.chapter, s.title, ".wav"))
+		if s.writer, err = OpenWAVWriter(path, s.title); err != nil {
+			// 文件不存在或不是之前写入的格式时重新创建
+			if s.writer, err = NewWAVWriter(path, format, s.title); err != nil {
+				return err
+			}
+		}
+	}
+	return s.writer.Append(format, data)
+}
+
+// Close 关闭当前章节的文件
+func (s *FileSpeaker) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.Close()
+	s.writer = nil
+	return err
+}
+
+// SynthesizeWAV 调用命令把文字合成为 WAV，返回音频格式和数据
+func SynthesizeWAV(ctx context.Context, command []string, text string) (WAVFormat, []byte, error) {
+	tmp, err := os.CreateTemp("", "novel-reader-*.wav")
+	if err != nil {
+		return WAVFormat{}, nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := runCommand(ctx, command, text, tmp.Name()); err != nil {
+		return WAVFormat{}, nil, err
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return WAVFormat{}, nil, err
+	}
+	defer f.Close()
+	return ReadWAV(f)
+}
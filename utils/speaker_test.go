@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	sentences := SplitSentences([]string{
+		"他说：“走吧。”她没有回答……  天黑了",
+		"",
+		"真的吗？！是的",
+	})
+	want := []Sentence{
+		{0, 0, "他说：“走吧。”"},
+		{0, 8, "她没有回答……"},
+		{0, 17, "天黑了"},
+		{2, 0, "真的吗？！"},
+		{2, 5, "是的"},
+	}
+	if len(sentences) != len(want) {
+		t.Fatalf("应分成 %d 句，实际 %d 句: %v", len(want), len(sentences), sentences)
+	}
+	for i := range want {
+		if sentences[i] != want[i] {
+			t.Errorf("第 %d 句应为 %v，实际 %v", i, want[i], sentences[i])
+		}
+	}
+}
+
+func TestWAVWriter(t *testing.T) {
+	format := WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 16000, ByteRate: 32000, BlockAlign: 2, BitsPerSample: 16}
+	path := filepath.Join(t.TempDir(), ChapterFileName(1, "第一章 开始", ".wav"))
+	if filepath.Base(path) != "0001_第一章_开始.wav" {
+		t.Errorf("文件名有误: %s", filepath.Base(path))
+	}
+
+	w, err := NewWAVWriter(path, format, "第一章 开始")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(format, []byte{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(format, []byte{5, 6}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(WAVFormat{SampleRate: 8000}, []byte{7, 8}); err == nil {
+		t.Error("格式不一致时应返回错误")
+	}
+	w.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("INAM")) || !bytes.Contains(data, []byte("第一章 开始")) {
+		t.Error("应写入标题")
+	}
+	got, audio, err := ReadWAV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != format || !bytes.Equal(audio, []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("读取结果有误: %v %v", got, audio)
+	}
+}
+
+func TestFileSpeaker(t *testing.T) {
+	dir := t.TempDir()
+	format := WAVFormat{AudioFormat: 1, Channels: 1, SampleRate: 16000, ByteRate: 32000, BlockAlign: 2, BitsPerSample: 16}
+	sample := filepath.Join(dir, "sample.wav")
+	w, err := NewWAVWriter(sample, format, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append(format, []byte{1, 2})
+	w.Close()
+
+	output := filepath.Join(dir, "out")
+	speak := func(s *FileSpeaker, index int, title string) {
+		if err := s.StartChapter(index, title); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Speak(context.Background(), "一句话"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &FileSpeaker{Command: []string{"cp", sample, "{file}"}, Dir: output}
+	speak(s, 3, "第三章")
+	speak(s, 3, "第三章")
+	s.Close()
+	// 重新打开后同一章仍接在原来的文件后面
+	s = &FileSpeaker{Command: s.Command, Dir: output}
+	speak(s, 3, "第三章")
+	speak(s, 4, "第四章")
+	s.Close()
+
+	entries, err := os.ReadDir(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "0003_第三章.wav" || entries[1].Name() != "0004_第四章.wav" {
+		t.Fatalf("应按章节序号各写一个文件，实际 %v", entries)
+	}
+	data, err := os.ReadFile(filepath.Join(output, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, audio, err := ReadWAV(bytes.NewReader(data)); err != nil || !bytes.Equal(audio, []byte{1, 2, 1, 2, 1, 2}) {
+		t.Errorf("同一章应追加到同一个文件，实际 %v %v", audio, err)
+	}
+}
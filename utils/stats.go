@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"time"
+)
+
+// dayFormat 每日统计的日期格式
+const dayFormat = "2006-01-02"
+
+// DayStats 一段时间内的阅读时长和字数
+type DayStats struct {
+	Seconds int `json:"seconds"`
+	Chars   int `json:"chars"`
+}
+
+// ReadingStats 一本书的阅读统计
+type ReadingStats struct {
+	Seconds  int                 `json:"seconds"`
+	Chars    int                 `json:"chars"`
+	Sessions int                 `json:"sessions"`
+	Daily    map[string]DayStats `json:"daily,omitempty"` // 按日期统计
+}
+
+// Reading 一次记录的阅读量
+type Reading struct {
+	Time       time.Time
+	Seconds    int
+	Chars      int
+	NewSession bool // 是否为新一次阅读的开始
+}
+
+// Speed 阅读速度，单位字/分钟
+func (s ReadingStats) Speed() float64 {
+	if s.Seconds <= 0 {
+		return 0
+	}
+	return float64(s.Chars) / float64(s.Seconds) * 60
+}
+
+// Between 统计 [from, to) 之间的阅读量
+func (s ReadingStats) Between(from time.Time, to time.Time) DayStats {
+	var total DayStats
+	for day, stats := range s.Daily {
+		t, err := time.ParseInLocation(dayFormat, day, from.Location())
+		if err != nil || t.Before(from) || !t.Before(to) {
+			continue
+		}
+		total.Seconds += stats.Seconds
+		total.Chars += stats.Chars
+	}
+	return total
+}
+
+// BookProgress 全书的阅读进度，0-100，不知道章节数时返回 -1
+func (e HistoryEntry) BookProgress() float64 {
+	if e.ChapterTotal <= 0 {
+		return -1
+	}
+	return (float64(e.ChapterIndex) + e.Progress/100) / float64(e.ChapterTotal) * 100
+}
+
+// Remaining 按当前阅读速度和本章字数估算读完全书还需要的时间，无法估算时返回 0
+func (e HistoryEntry) Remaining() time.Duration {
+	speed := e.Stats.Speed()
+	if speed <= 0 || e.ChapterTotal <= 0 || e.ChapterChars <= 0 {
+		return 0
+	}
+	chapters := float64(e.ChapterTotal-e.ChapterIndex) - e.Progress/100
+	minutes := chapters * float64(e.ChapterChars) / speed
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+// AddReading 累加一本书的阅读时长和字数
+func (hm *HistoryManager) AddReading(originURL string, reading Reading) error {
+	return hm.updateEntry(originURL, func(entry *HistoryEntry) {
+		stats := &entry.Stats
+		stats.Seconds += reading.Seconds
+		stats.Chars += reading.Chars
+		if reading.NewSession {
+			stats.Sessions++
+		}
+		if stats.Daily == nil {
+			stats.Daily = map[string]DayStats{}
+		}
+		day := reading.Time.Format(dayFormat)
+		daily := stats.Daily[day]
+		daily.Seconds += reading.Seconds
+		daily.Chars += reading.Chars
+		stats.Daily[day] = daily
+	})
+}
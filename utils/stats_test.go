@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddReading(t *testing.T) {
+	setupHome(t)
+	hm := NewHistoryManager()
+
+	day := time.Date(2024, 5, 6, 21, 0, 0, 0, time.Local)
+	readings := []Reading{
+		{Time: day, Seconds: 600, Chars: 3000, NewSession: true},
+		{Time: day, Seconds: 60, Chars: 300},
+		{Time: day.AddDate(0, 0, 1), Seconds: 540, Chars: 2700, NewSession: true},
+	}
+	for _, reading := range readings {
+		if err := hm.AddReading("book", reading); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 保存阅读位置时不应覆盖统计
+	if err := hm.Save(HistoryEntry{OriginURL: "book", ChapterIndex: 9, ChapterTotal: 100, ChapterChars: 3000, Progress: 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, _ := hm.Get("book")
+	stats := entry.Stats
+	if stats.Seconds != 1200 || stats.Chars != 6000 || stats.Sessions != 2 {
+		t.Errorf("统计有误: %+v", stats)
+	}
+	if speed := stats.Speed(); speed != 300 {
+		t.Errorf("阅读速度应为 300 字/分钟，实际 %.1f", speed)
+	}
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+	if daily := stats.Between(from, from.AddDate(0, 0, 1)); daily.Seconds != 660 {
+		t.Errorf("第一天的阅读时长应为 660 秒，实际 %d", daily.Seconds)
+	}
+
+	if progress := entry.BookProgress(); progress != 9.5 {
+		t.Errorf("全书进度应为 9.5%%，实际 %.2f", progress)
+	}
+	// 剩余 90.5 章，每章 3000 字，每分钟 300 字
+	if remaining := entry.Remaining(); remaining != 905*time.Minute {
+		t.Errorf("剩余时间应为 905 分钟，实际 %s", remaining)
+	}
+}
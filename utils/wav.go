@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// WAVFormat WAV 文件的 fmt 块
+type WAVFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// ReadWAV 读取 WAV 文件的格式和音频数据
+func ReadWAV(r io.Reader) (WAVFormat, []byte, error) {
+	var format WAVFormat
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return format, nil, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return format, nil, errors.New("不是 WAV 文件")
+	}
+
+	hasFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return format, nil, errors.New("WAV 文件中没有音频数据")
+		}
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return format, nil, err
+			}
+			if size < 16 {
+				return format, nil, errors.New("WAV 文件格式有误")
+			}
+			format = WAVFormat{
+				AudioFormat:   binary.LittleEndian.Uint16(data[0:2]),
+				Channels:      binary.LittleEndian.Uint16(data[2:4]),
+				SampleRate:    binary.LittleEndian.Uint32(data[4:8]),
+				ByteRate:      binary.LittleEndian.Uint32(data[8:12]),
+				BlockAlign:    binary.LittleEndian.Uint16(data[12:14]),
+				BitsPerSample: binary.LittleEndian.Uint16(data[14:16]),
+			}
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return format, nil, errors.New("WAV 文件格式有误")
+			}
+			// 流式输出的 WAV 文件长度可能为 0 或 0xFFFFFFFF，读到文件末尾
+			if size == 0 || size == 0xFFFFFFFF {
+				data, err := io.ReadAll(r)
+				return format, data, err
+			}
+			data := make([]byte, size)
+			n, err := io.ReadFull(r, data)
+			if err == io.ErrUnexpectedEOF {
+				err = nil
+			}
+			return format, data[:n], err
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return format, nil, err
+			}
+		}
+	}
+}
+
+// WAVWriter 逐段追加音频数据的 WAV 文件，每次追加后更新文件头中的长度
+type WAVWriter struct {
+	file   *os.File
+	format WAVFormat
+	size   uint32
+	title  string
+}
+
+// NewWAVWriter 创建 WAV 文件，title 写入 LIST INFO 块的 INAM 作为标题
+func NewWAVWriter(path string, format WAVFormat, title string) (*WAVWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &WAVWriter{file: file, format: format, title: title}
+	if err := w.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// OpenWAVWriter 打开 NewWAVWriter 以同样标题创建的文件，继续追加音频数据
+func OpenWAVWriter(path string, title string) (*WAVWriter, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	format, data, err := ReadWAV(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &WAVWriter{file: file, format: format, size: uint32(len(data)), title: title}
+	// 文件头的长度不同时，追加的数据不会紧接在原来的数据后面
+	info, err := file.Stat()
+	if err != nil || info.Size() != int64(44+len(w.info()))+int64(w.size) {
+		file.Close()
+		return nil, errors.New("WAV 文件格式与追加的不一致")
+	}
+	return w, nil
+}
+
+// Format 返回文件的音频格式
+func (w *WAVWriter) Format() WAVFormat {
+	return w.format
+}
+
+// info LIST INFO 块
+func (w *WAVWriter) info() []byte {
+	if w.title == "" {
+		return nil
+	}
+	name := append([]byte(w.title), 0)
+	if len(name)%2 == 1 {
+		name = append(name, 0)
+	}
+	chunk := make([]byte, 0, 20+len(name))
+	chunk = append(chunk, "LIST"...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(12+len(name)))
+	chunk = append(chunk, "INFO"...)
+	chunk = append(chunk, "INAM"...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(name)))
+	return append(chunk, name...)
+}
+
+// writeHeader 写入文件头、fmt 块、标题和 data 块的头部
+func (w *WAVWriter) writeHeader() error {
+	info := w.info()
+	header := make([]byte, 0, 44+len(info))
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(36+len(info))+w.size)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, w.format.AudioFormat)
+	header = binary.LittleEndian.AppendUint16(header, w.format.Channels)
+	header = binary.LittleEndian.AppendUint32(header, w.format.SampleRate)
+	header = binary.LittleEndian.AppendUint32(header, w.format.ByteRate)
+	header = binary.LittleEndian.AppendUint16(header, w.format.BlockAlign)
+	header = binary.LittleEndian.AppendUint16(header, w.format.BitsPerSample)
+	header = append(header, info...)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, w.size)
+	_, err := w.file.WriteAt(header, 0)
+	return err
+}
+
+// Append 追加音频数据，格式需与文件一致
+func (w *WAVWriter) Append(format WAVFormat, data []byte) error {
+	if format != w.format {
+		return errors.New("音频格式与文件不一致")
+	}
+	if _, err := w.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := w.file.Write(data); err != nil {
+		return err
+	}
+	w.size += uint32(len(data))
+	return w.writeHeader()
+}
+
+// Duration 返回已写入音频的时长
+func (w *WAVWriter) Duration() time.Duration {
+	if w.format.ByteRate == 0 {
+		return 0
+	}
+	return time.Duration(w.size) * time.Second / time.Duration(w.format.ByteRate)
+}
+
+// Close 关闭文件
+func (w *WAVWriter) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Line 折行后的一行文本
+type Line struct {
+	Text      string
+	Paragraph int // 所属段落序号
+	Offset    int // 在段落中的起始字符（rune）偏移
+}
+
+const (
+	// noStartChars 不能出现在行首的标点（避头）
+	noStartChars = "，。、；：？！）》」』”’】〕〉｝…—·,.;:?!)]}%"
+	// noEndChars 不能出现在行尾的标点（避尾）
+	noEndChars = "（《「『“‘【〔〈｛([{"
+	// maxKinsokuShift 为满足避头尾规则最多回退的字符数
+	maxKinsokuShift = 4
+)
+
+// SplitParagraphs 统一换行符并拆分出非空段落
+func SplitParagraphs(str string) []string {
+	str = strings.ReplaceAll(str, "\r\n", "\n")
+	str = strings.ReplaceAll(str, "\r", "\n")
+
+	var paragraphs []string
+	for _, line := range strings.Split(str, "\n") {
+		if strings.TrimSpace(line) != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return paragraphs
+}
+
+// WordWrap 按显示宽度折行，全角字符占两列，并遵循避头尾规则
+func WordWrap(str string, maxWidth int) []Line {
+	if maxWidth < 2 {
+		maxWidth = 2
+	}
+
+	var lines []Line
+	for i, paragraph := range SplitParagraphs(str) {
+		runes := []rune(paragraph)
+		for start := 0; start < len(runes); {
+			end := breakPoint(runes, start, maxWidth)
+			lines = append(lines, Line{
+				Text:      string(runes[start:end]),
+				Paragraph: i,
+				Offset:    start,
+			})
+			start = end
+		}
+	}
+	return lines
+}
+
+// breakPoint 返回从 start 开始的一行的结束位置
+func breakPoint(runes []rune, start int, maxWidth int) int {
+	end, width := start, 0
+	for end < len(runes) {
+		w := runewidth.RuneWidth(runes[end])
+		if width+w > maxWidth {
+			break
+		}
+		width += w
+		end++
+	}
+	if end == start {
+		// 单个字符超过宽度时也要占一行
+		return start + 1
+	}
+	if end >= len(runes) {
+		return end
+	}
+
+	// 避头尾：下一行不能以闭合标点开头，本行不能以开启标点结尾
+	brk := end
+	for brk > start+1 && end-brk < maxKinsokuShift &&
+		(strings.ContainsRune(noStartChars, runes[brk]) || strings.ContainsRune(noEndChars, runes[brk-1])) {
+		brk--
+	}
+	if !strings.ContainsRune(noStartChars, runes[brk]) && !strings.ContainsRune(noEndChars, runes[brk-1]) {
+		end = brk
+	}
+
+	// 不拆开英文单词
+	if isWordRune(runes[end-1]) && isWordRune(runes[end]) {
+		for i := end - 1; i > start; i-- {
+			if unicode.IsSpace(runes[i]) {
+				return i + 1
+			}
+		}
+	}
+	return end
+}
+
+// isWordRune 判断是否为英文单词中的字符
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// FindLine 返回包含指定段落偏移的行号，找不到时返回最接近的行
+func FindLine(lines []Line, paragraph int, offset int) int {
+	found := 0
+	for i, line := range lines {
+		if line.Paragraph > paragraph || (line.Paragraph == paragraph && line.Offset > offset) {
+			break
+		}
+		found = i
+	}
+	return found
+}
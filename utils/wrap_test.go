@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordWrap(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		width    int
+		expected []string
+	}{
+		{
+			name:     "全角字符占两列",
+			text:     "一二三四五六七",
+			width:    6,
+			expected: []string{"一二三", "四五六", "七"},
+		},
+		{
+			name:     "半角字符",
+			text:     "abcdefgh",
+			width:    3,
+			expected: []string{"abc", "def", "gh"},
+		},
+		{
+			name:     "避头：句号不在行首",
+			text:     "一二三。四五",
+			width:    6,
+			expected: []string{"一二", "三。四", "五"},
+		},
+		{
+			name:     "避尾：引号不在行尾",
+			text:     "一二“三四”",
+			width:    6,
+			expected: []string{"一二", "“三四”"},
+		},
+		{
+			name:     "不拆开英文单词",
+			text:     "hello world",
+			width:    8,
+			expected: []string{"hello ", "world"},
+		},
+		{
+			name:     "空行",
+			text:     "甲\r\n\r\n乙",
+			width:    10,
+			expected: []string{"甲", "乙"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var actual []string
+			for _, line := range WordWrap(c.text, c.width) {
+				actual = append(actual, line.Text)
+			}
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("期望: %q\n实际: %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindLineAfterRewrap(t *testing.T) {
+	text := "第一段第一段第一段\n第二段第二段第二段第二段"
+	narrow := WordWrap(text, 6)
+	// 第二段的第二行
+	cursor := 4
+	if narrow[cursor].Paragraph != 1 {
+		t.Fatalf("折行结果不符合预期: %+v", narrow)
+	}
+
+	wide := WordWrap(text, 40)
+	line := FindLine(wide, narrow[cursor].Paragraph, narrow[cursor].Offset)
+	if line != 1 {
+		t.Errorf("重新折行后应定位到第二段，实际: %d", line)
+	}
+}